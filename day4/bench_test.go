@@ -0,0 +1,88 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// the synthetic game the benchmarks play, 100k 5x5 boards filled from the
+// numbers the 1000 calls go through
+const (
+	benchBoards = 100000
+	benchSize   = 5
+	benchCalls  = 1000
+)
+
+// BenchmarkScan plays the game by scanning every board for each call
+func BenchmarkScan(b *testing.B) {
+	g := syntheticGame(rand.New(rand.NewSource(1)), benchBoards, benchSize, benchCalls)
+	rules := winRules{linesRule}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resetGrids(g.grids)
+		playScan(g, rules)
+	}
+}
+
+// BenchmarkIndexed plays the same game through the call index
+func BenchmarkIndexed(b *testing.B) {
+	g := syntheticGame(rand.New(rand.NewSource(1)), benchBoards, benchSize, benchCalls)
+	rules := winRules{linesRule}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resetGrids(g.grids)
+		play(g, rules)
+	}
+}
+
+// playScan is how games used to be played before the call index, every call
+// is checked against every cell of the boards that haven't won
+func playScan(g game, rules winRules) []win {
+	timeline := make([]win, 0, len(g.grids))
+	won := make([]bool, len(g.grids))
+	for i, call := range g.calls {
+		if len(timeline) == len(g.grids) {
+			break
+		}
+
+		for j := range g.grids {
+			if won[j] {
+				continue
+			}
+
+			g.grids[j].markCall(call)
+			if rules.hasWon(&g.grids[j]) {
+				won[j] = true
+				timeline = append(timeline, win{
+					Board:  j + 1,
+					Won:    true,
+					Place:  len(timeline) + 1,
+					Call:   i,
+					Number: call,
+					Score:  g.grids[j].score(call),
+				})
+			}
+		}
+	}
+
+	return timeline
+}
+
+// syntheticGame calls every number from 0 to calls-1 in a random order, the
+// boards are filled from the same range
+func syntheticGame(rng *rand.Rand, boards, size, calls int) game {
+	grids := make([]grid, boards)
+	for i := range grids {
+		grids[i] = randomGrid(rng, size, size, calls)
+	}
+
+	return game{
+		calls: rng.Perm(calls),
+		grids: grids,
+		index: newCallIndex(grids),
+	}
+}
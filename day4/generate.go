@@ -0,0 +1,404 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// genOptions describes the game to generate. target is the 0 based board
+// that should win first, or last when last is set, -1 leaves the winners up
+// to chance. at is the call index the target should win on, -1 lets the
+// generator pick.
+type genOptions struct {
+	boards int
+	rows   int
+	cols   int
+	max    int
+	target int
+	last   bool
+	at     int
+}
+
+const genAttempts = 100
+
+var errUnsatisfiable = errors.New("unable to satisfy the constraint")
+
+// generate creates a game whose calls are every number in [0, max) in some
+// order. Constraints are built around the puzzle's rule of full rows and
+// columns: the target wins through its own row and the calls around that row
+// are picked so no other board gets in the way. Boards are redrawn until the
+// constraint holds.
+func generate(rng *rand.Rand, opts genOptions) (game, error) {
+	if opts.rows < 1 || opts.cols < 1 || opts.rows*opts.cols > opts.max {
+		return game{}, fmt.Errorf("unable to fill %dx%d boards with numbers below %d", opts.rows, opts.cols, opts.max)
+	}
+	if opts.target >= opts.boards {
+		return game{}, fmt.Errorf("target board %d is out of range", opts.target+1)
+	}
+	if opts.target >= 0 && opts.at >= 0 && (opts.at < opts.cols-1 || opts.at >= opts.max) {
+		return game{}, fmt.Errorf("a board can't win on call %d", opts.at)
+	}
+
+	for attempt := 0; attempt < genAttempts; attempt++ {
+		grids := make([]grid, opts.boards)
+		for i := range grids {
+			grids[i] = randomGrid(rng, opts.rows, opts.cols, opts.max)
+		}
+		g := game{grids: grids, index: newCallIndex(grids)}
+
+		if opts.target < 0 {
+			g.calls = rng.Perm(opts.max)
+			return g, nil
+		}
+
+		var err error
+		if opts.last {
+			g.calls, err = lastCalls(rng, g, opts)
+		} else {
+			g.calls, err = firstCalls(rng, g, opts)
+		}
+		if errors.Is(err, errUnsatisfiable) {
+			continue
+		}
+		if err != nil {
+			return game{}, err
+		}
+
+		if verifyGenerated(g, opts) {
+			resetGrids(g.grids)
+			return g, nil
+		}
+	}
+
+	return game{}, fmt.Errorf("%w after %d attempts", errUnsatisfiable, genAttempts)
+}
+
+// firstCalls calls numbers that don't complete a line on any board, then the
+// target's row so its last number lands on the chosen call
+func firstCalls(rng *rand.Rand, g game, opts genOptions) ([]int, error) {
+	t := &g.grids[opts.target]
+	r := rng.Intn(t.rows)
+	line := make(map[int]bool, t.cols)
+	for j := 0; j < t.cols; j++ {
+		line[t.cells[r][j].num] = true
+	}
+
+	at := opts.at
+	if at < 0 {
+		at = t.cols - 1
+	}
+	fillers := at + 1 - t.cols
+
+	var calls []int
+	used := make(map[int]bool)
+	for _, n := range rng.Perm(opts.max) {
+		if len(calls) == fillers {
+			break
+		}
+		if line[n] || completesLine(g, n) {
+			continue
+		}
+		markIndexed(g, n)
+		calls = append(calls, n)
+		used[n] = true
+	}
+	if len(calls) < fillers {
+		return nil, errUnsatisfiable
+	}
+
+	for j := 0; j < t.cols; j++ {
+		n := t.cells[r][j].num
+		calls = append(calls, n)
+		used[n] = true
+	}
+
+	return appendUnused(rng, calls, used, opts.max), nil
+}
+
+// lastCalls finishes a line on every other board, then calls the rest of a
+// row of the target. the other boards can use the target's numbers as long as
+// none of its rows or columns fill up early. at can only push the target's
+// win later by padding with numbers that don't finish one of its lines
+func lastCalls(rng *rand.Rand, g game, opts genOptions) ([]int, error) {
+	t := &g.grids[opts.target]
+
+	var calls []int
+	used := make(map[int]bool)
+	call := func(n int) {
+		if used[n] {
+			return
+		}
+		markIndexed(g, n)
+		calls = append(calls, n)
+		used[n] = true
+	}
+
+	for _, b := range rng.Perm(len(g.grids)) {
+		if b == opts.target || linesRule(&g.grids[b]) {
+			continue
+		}
+
+		line, ok := safeLine(rng, g, &g.grids[b], opts.target, used)
+		if !ok {
+			return nil, errUnsatisfiable
+		}
+		for _, n := range line {
+			call(n)
+		}
+	}
+
+	// the row with the fewest marks leaves the most room for padding
+	r := 0
+	for i := 1; i < t.rows; i++ {
+		if t.cells[i][t.cols].num < t.cells[r][t.cols].num {
+			r = i
+		}
+	}
+	inRow := make(map[int]bool, t.cols)
+	var rest []int
+	for j := 0; j < t.cols; j++ {
+		n := t.cells[r][j].num
+		inRow[n] = true
+		if !used[n] {
+			rest = append(rest, n)
+		}
+	}
+
+	if opts.at >= 0 {
+		fillers := opts.at + 1 - len(rest)
+		if len(calls) > fillers {
+			return nil, errUnsatisfiable
+		}
+		for _, n := range rng.Perm(opts.max) {
+			if len(calls) == fillers {
+				break
+			}
+			if !used[n] && !inRow[n] && newTargetMarks(g, opts.target, []int{n}, used) != nil {
+				call(n)
+			}
+		}
+		if len(calls) < fillers {
+			return nil, errUnsatisfiable
+		}
+	}
+
+	for _, n := range rest {
+		call(n)
+	}
+
+	return appendUnused(rng, calls, used, opts.max), nil
+}
+
+// safeLine picks a random row or column of the board whose numbers can all
+// be called without finishing a line of the target, preferring the ones that
+// mark the fewest of the target's numbers
+func safeLine(rng *rand.Rand, g game, b *grid, target int, used map[int]bool) ([]int, bool) {
+	var lines [][]int
+	for i := 0; i < b.rows; i++ {
+		var line []int
+		for j := 0; j < b.cols; j++ {
+			line = append(line, b.cells[i][j].num)
+		}
+		lines = append(lines, line)
+	}
+	for j := 0; j < b.cols; j++ {
+		var line []int
+		for i := 0; i < b.rows; i++ {
+			line = append(line, b.cells[i][j].num)
+		}
+		lines = append(lines, line)
+	}
+
+	var best []int
+	fewest := -1
+	for _, i := range rng.Perm(len(lines)) {
+		marks := newTargetMarks(g, target, lines[i], used)
+		if marks == nil {
+			continue
+		}
+		if fewest < 0 || *marks < fewest {
+			best, fewest = lines[i], *marks
+		}
+	}
+
+	return best, best != nil
+}
+
+// newTargetMarks is the # of the target's cells calling the numbers would
+// mark, nil when that would finish one of the target's rows or columns
+func newTargetMarks(g game, target int, nums []int, used map[int]bool) *int {
+	t := &g.grids[target]
+	rows := make([]int, t.rows)
+	cols := make([]int, t.cols)
+	var marks int
+	for _, n := range nums {
+		if used[n] {
+			continue
+		}
+		for _, loc := range g.index[n] {
+			if loc.board != target {
+				continue
+			}
+			rows[loc.row]++
+			cols[loc.col]++
+			marks++
+			if t.cells[loc.row][t.cols].num+rows[loc.row] == t.cols || t.cells[t.rows][loc.col].num+cols[loc.col] == t.rows {
+				return nil
+			}
+		}
+	}
+
+	return &marks
+}
+
+// completesLine reports whether calling n would finish a row or column on any
+// board
+func completesLine(g game, n int) bool {
+	for _, loc := range g.index[n] {
+		b := &g.grids[loc.board]
+		if b.cells[loc.row][b.cols].num+1 == b.cols || b.cells[b.rows][loc.col].num+1 == b.rows {
+			return true
+		}
+	}
+
+	return false
+}
+
+func markIndexed(g game, n int) {
+	for _, loc := range g.index[n] {
+		g.grids[loc.board].mark(loc.row, loc.col)
+	}
+}
+
+// appendUnused finishes the calls off with every number not called yet
+func appendUnused(rng *rand.Rand, calls []int, used map[int]bool, max int) []int {
+	for _, n := range rng.Perm(max) {
+		if !used[n] {
+			calls = append(calls, n)
+		}
+	}
+
+	return calls
+}
+
+// verifyGenerated plays the game to make sure the target won alone, on the
+// chosen call when there is one
+func verifyGenerated(g game, opts genOptions) bool {
+	resetGrids(g.grids)
+	timeline := play(g, winRules{linesRule})
+
+	var w, other win
+	var ok bool
+	if opts.last {
+		w, ok = last(timeline)
+		if ok && w.Place > 1 {
+			other = timeline[w.Place-2]
+		}
+	} else {
+		w, ok = first(timeline)
+		if ok && len(timeline) > 1 {
+			other = timeline[1]
+		}
+	}
+
+	switch {
+	case !ok || w.Board != opts.target+1:
+		return false
+	case opts.at >= 0 && w.Call != opts.at:
+		return false
+	case other.Won && other.Call == w.Call:
+		return false
+	}
+
+	return true
+}
+
+// randomGrid fills a board with unique numbers in [0, max)
+func randomGrid(rng *rand.Rand, rows, cols, max int) grid {
+	seen := make(map[int]struct{}, rows*cols)
+	cells := make([][]cell, rows)
+	for i := range cells {
+		cells[i] = make([]cell, cols)
+		for j := range cells[i] {
+			n := rng.Intn(max)
+			for {
+				if _, ok := seen[n]; !ok {
+					break
+				}
+				n = rng.Intn(max)
+			}
+			seen[n] = struct{}{}
+			cells[i][j] = cell{num: n}
+		}
+	}
+
+	return newGrid(cells)
+}
+
+func generateFile(path string, opts genOptions, seed int64) error {
+	g, err := generate(rand.New(rand.NewSource(seed)), opts)
+	if err != nil {
+		return err
+	}
+
+	if path == "-" {
+		return writeGame(os.Stdout, g)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("unable to create file: %w", err)
+	}
+
+	if err := writeGame(f, g); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// writeGame writes the game in the same form as the puzzle input
+func writeGame(w io.Writer, g game) error {
+	bw := bufio.NewWriter(w)
+
+	calls := make([]string, len(g.calls))
+	for i := range g.calls {
+		calls[i] = strconv.Itoa(g.calls[i])
+	}
+	fmt.Fprintln(bw, strings.Join(calls, ","))
+
+	var width int
+	for i := range g.grids {
+		for _, row := range g.grids[i].cells[:g.grids[i].rows] {
+			for _, c := range row[:g.grids[i].cols] {
+				if l := len(strconv.Itoa(c.num)); l > width {
+					width = l
+				}
+			}
+		}
+	}
+
+	for i := range g.grids {
+		fmt.Fprintln(bw)
+		for _, row := range g.grids[i].cells[:g.grids[i].rows] {
+			nums := make([]string, g.grids[i].cols)
+			for j := range nums {
+				nums[j] = fmt.Sprintf("%*d", width, row[j].num)
+			}
+			fmt.Fprintln(bw, strings.Join(nums, " "))
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("unable to write game: %w", err)
+	}
+
+	return nil
+}
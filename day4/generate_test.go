@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name   string
+		target int
+		last   bool
+		at     int
+	}{
+		{name: "first", target: 0, at: -1},
+		{name: "first at 10", target: 4, at: 10},
+		{name: "last", target: 0, last: true, at: -1},
+		{name: "last at 90", target: 6, last: true, at: 90},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		for seed := int64(1); seed <= 3; seed++ {
+			opts := genOptions{boards: 100, rows: 5, cols: 5, max: 100, target: tt.target, last: tt.last, at: tt.at}
+			g, err := generate(rand.New(rand.NewSource(seed)), opts)
+			if err != nil {
+				t.Fatalf("%s, seed %d: unable to generate: %v", tt.name, seed, err)
+			}
+
+			timeline := play(g, winRules{linesRule})
+			w, other := timeline[0], win{}
+			if len(timeline) > 1 {
+				other = timeline[1]
+			}
+			if tt.last {
+				w, _ = last(timeline)
+				other = timeline[w.Place-2]
+				if w.Place != len(g.grids) {
+					t.Errorf("%s, seed %d: last winner came %d of %d", tt.name, seed, w.Place, len(g.grids))
+				}
+			}
+			if w.Board != tt.target+1 {
+				t.Errorf("%s, seed %d: board %d won, want %d", tt.name, seed, w.Board, tt.target+1)
+			}
+			if other.Won && other.Call == w.Call {
+				t.Errorf("%s, seed %d: board %d won on the same call as the target", tt.name, seed, other.Board)
+			}
+			if tt.at >= 0 && w.Call != tt.at {
+				t.Errorf("%s, seed %d: target won on call %d, want %d", tt.name, seed, w.Call, tt.at)
+			}
+
+			// the game has to read back the same
+			var buf bytes.Buffer
+			if err := writeGame(&buf, g); err != nil {
+				t.Fatalf("unable to write game: %v", err)
+			}
+			path := filepath.Join(dir, "game.txt")
+			if err := ioutil.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+				t.Fatalf("unable to write file: %v", err)
+			}
+			read, err := getGame(path)
+			if err != nil {
+				t.Fatalf("%s, seed %d: unable to read the game back: %v", tt.name, seed, err)
+			}
+			if !sameGame(g, read) {
+				t.Errorf("%s, seed %d: game read back differently", tt.name, seed)
+			}
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	for _, opts := range []genOptions{
+		{boards: 2, rows: 5, cols: 5, max: 20, target: -1, at: -1},
+		{boards: 2, rows: 2, cols: 2, max: 20, target: 2, at: -1},
+		{boards: 2, rows: 2, cols: 2, max: 20, target: 0, at: 0},
+		{boards: 2, rows: 2, cols: 2, max: 20, target: 0, at: 20},
+	} {
+		if _, err := generate(rand.New(rand.NewSource(1)), opts); err == nil {
+			t.Errorf("%+v: expected an error", opts)
+		}
+	}
+}
+
+func sameGame(a, b game) bool {
+	if len(a.calls) != len(b.calls) || len(a.grids) != len(b.grids) {
+		return false
+	}
+	for i := range a.calls {
+		if a.calls[i] != b.calls[i] {
+			return false
+		}
+	}
+	for i := range a.grids {
+		if a.grids[i].rows != b.grids[i].rows || a.grids[i].cols != b.grids[i].cols {
+			return false
+		}
+		for r := 0; r < a.grids[i].rows; r++ {
+			for c := 0; c < a.grids[i].cols; c++ {
+				if a.grids[i].cells[r][c].num != b.grids[i].cells[r][c].num {
+					return false
+				}
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,26 @@
+package main
+
+// location is a cell on one of the boards of a game
+type location struct {
+	board int
+	row   int
+	col   int
+}
+
+// callIndex maps a number to every cell it appears in. locations are kept in
+// board order so boards that win on the same call are seen in input order
+type callIndex map[int][]location
+
+func newCallIndex(grids []grid) callIndex {
+	index := make(callIndex)
+	for b := range grids {
+		for i := 0; i < grids[b].rows; i++ {
+			for j := 0; j < grids[b].cols; j++ {
+				n := grids[b].cells[i][j].num
+				index[n] = append(index[n], location{board: b, row: i, col: j})
+			}
+		}
+	}
+
+	return index
+}
@@ -2,37 +2,90 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 )
 
-const (
-	input       = "day4/input.txt"
-	metaCellIdx = 5
-)
+const input = "day4/input.txt"
+
+// grid holds a board of any size with one extra row/column to store the # of
+// numbers that have been marked in each row/column
+type grid struct {
+	rows  int
+	cols  int
+	cells [][]cell
+}
 
-// one extra row/column to store the # of numbers that have been marked
-type grid [6][6]cell
+func newGrid(rows [][]cell) grid {
+	g := grid{
+		rows: len(rows),
+		cols: len(rows[0]),
+	}
+	g.cells = make([][]cell, g.rows+1)
+	for i := range rows {
+		g.cells[i] = append(rows[i], cell{meta: true})
+	}
+	g.cells[g.rows] = metaRow(g.cols + 1)
+
+	return g
+}
 
+// markCall scans the whole board for the call, see callIndex for going
+// straight to the cells instead
 func (g *grid) markCall(call int) {
-	for i := 0; i < metaCellIdx; i++ {
-		for j := 0; j < metaCellIdx; j++ {
-			if g[i][j].num == call {
-				g[i][j].marked = true
-				g[i][metaCellIdx].num++
-				g[metaCellIdx][j].num++
+	for i := 0; i < g.rows; i++ {
+		for j := 0; j < g.cols; j++ {
+			if g.cells[i][j].num == call {
+				g.mark(i, j)
 			}
 		}
 	}
 }
 
+func (g *grid) mark(i, j int) {
+	if g.cells[i][j].marked {
+		return
+	}
+
+	g.cells[i][j].marked = true
+	g.cells[i][g.cols].num++
+	g.cells[g.rows][j].num++
+}
+
+// reset clears every mark so the board can be played again
+func (g *grid) reset() {
+	for i := 0; i < g.rows; i++ {
+		for j := 0; j < g.cols; j++ {
+			g.cells[i][j].marked = false
+		}
+		g.cells[i][g.cols].num = 0
+	}
+	for j := 0; j < g.cols; j++ {
+		g.cells[g.rows][j].num = 0
+	}
+}
+
+func resetGrids(grids []grid) {
+	for i := range grids {
+		grids[i].reset()
+	}
+}
+
 func (g *grid) hasWon() bool {
-	for i := 0; i < metaCellIdx; i++ {
-		if g[i][metaCellIdx].num == 5 || g[metaCellIdx][i].num == 5 {
+	for i := 0; i < g.rows; i++ {
+		if g.cells[i][g.cols].num == g.cols {
+			return true
+		}
+	}
+	for j := 0; j < g.cols; j++ {
+		if g.cells[g.rows][j].num == g.rows {
 			return true
 		}
 	}
@@ -41,10 +94,10 @@ func (g *grid) hasWon() bool {
 
 func (g *grid) score(justCalled int) int {
 	var sum int
-	for i := 0; i < metaCellIdx; i++ {
-		for j := 0; j < metaCellIdx; j++ {
-			if !g[i][j].marked {
-				sum += g[i][j].num
+	for i := 0; i < g.rows; i++ {
+		for j := 0; j < g.cols; j++ {
+			if !g.cells[i][j].marked {
+				sum += g.cells[i][j].num
 			}
 		}
 	}
@@ -60,132 +113,310 @@ type cell struct {
 
 // straight forward implementation for both parts. Only trick i used was
 // having an extra row and column that contained the # of marked numbers in
-// that row /column. That we can only reference those to see if a board has one
+// that row /column. That we can only reference those to see if a board has one.
+// Both parts come out of a single game in which every board is played until
+// it wins
 func main() {
-	if err := part1(); err != nil {
-		log.Fatalf("unable to complete part 1: %v", err)
+	path := flag.String("input", input, "path to the puzzle input")
+	win := flag.String("win", "lines", "comma separated win rules: lines, diagonals, corners, x, blackout, mask")
+	maskPath := flag.String("mask", "", "path to the mask file used by the mask win rule")
+	format := flag.String("timeline", "", "print the full timeline of wins as a table or json")
+	trials := flag.Int("montecarlo", 0, "estimate win probabilities over this many shuffled games")
+	workers := flag.Int("workers", runtime.NumCPU(), "# of goroutines running trials")
+	serve := flag.String("serve", "", "run a live game on this address instead of solving")
+	rows := flag.Int("rows", 5, "# of rows on generated boards")
+	cols := flag.Int("cols", 5, "# of columns on generated boards")
+	max := flag.Int("max", 100, "generated boards and calls use numbers below this")
+	seed := flag.Int64("seed", 1, "seed for anything random")
+	out := flag.String("generate", "", "write a generated game to this file, - for stdout, instead of solving")
+	boards := flag.Int("boards", 100, "# of boards in a generated game")
+	winFirst := flag.Int("win-first", 0, "board that should win first in a generated game")
+	winLast := flag.Int("win-last", 0, "board that should win last in a generated game")
+	at := flag.Int("at", -1, "call index the -win-first/-win-last board should win on")
+	flag.Parse()
+
+	if *out != "" {
+		opts := genOptions{
+			boards: *boards,
+			rows:   *rows,
+			cols:   *cols,
+			max:    *max,
+			target: -1,
+			at:     *at,
+		}
+		switch {
+		case *winFirst > 0 && *winLast > 0:
+			log.Fatalf("only one of -win-first and -win-last can be set")
+		case *winFirst > 0:
+			opts.target = *winFirst - 1
+		case *winLast > 0:
+			opts.target = *winLast - 1
+			opts.last = true
+		}
+
+		if err := generateFile(*out, opts, *seed); err != nil {
+			log.Fatalf("unable to generate game: %v", err)
+		}
+		return
 	}
 
-	if err := part2(); err != nil {
-		log.Fatalf("unable to complete part 2: %v", err)
+	rules, err := getWinRules(*win, *maskPath)
+	if err != nil {
+		log.Fatalf("unable to get win rules: %v", err)
 	}
 
-}
+	if *serve != "" {
+		b, err := newBingoServer(rules, *rows, *cols, *max, *seed)
+		if err != nil {
+			log.Fatalf("unable to create server: %v", err)
+		}
+		log.Printf("serving bingo on %s", *serve)
+		if err := http.ListenAndServe(*serve, b); err != nil {
+			log.Fatalf("unable to serve: %v", err)
+		}
+		return
+	}
 
-func part1() error {
-	s, err := inputScanner(input)
+	g, err := getGame(*path)
 	if err != nil {
-		log.Fatalf("unable to get input scanner: %v", err)
+		log.Fatalf("unable to get game: %v", err)
 	}
 
-	calls, err := getCallouts(s)
-	if err != nil {
-		return fmt.Errorf("unable to get calls: %w", err)
+	for _, w := range checkCalls(g) {
+		log.Printf("warning: %v", w)
 	}
 
-	grids, err := getGrids(s)
-	if err != nil {
-		return fmt.Errorf("unable to get grids: %w", err)
+	if *trials > 0 {
+		if *workers < 1 {
+			log.Fatalf("need at least one worker")
+		}
+		e := monteCarlo(g, rules, *trials, *workers, *seed)
+		if err := writeEstimate(os.Stdout, e); err != nil {
+			log.Fatalf("unable to write estimate: %v", err)
+		}
+		return
 	}
 
-	if err := s.Err(); err != nil {
-		log.Fatalf("encountered error while scanning: %v", err)
+	timeline := play(g, rules)
+	part1(timeline)
+	part2(timeline)
+
+	if *format != "" {
+		if err := writeTimeline(os.Stdout, *format, timeline); err != nil {
+			log.Fatalf("unable to write timeline: %v", err)
+		}
+	}
+}
+
+func part1(timeline []win) {
+	w, ok := first(timeline)
+	if !ok {
+		return
 	}
 
-	for i := range calls {
-		for j := range grids {
-			grids[j].markCall(calls[i])
-			if grids[j].hasWon() {
-				fmt.Printf("Grid %d has won!\n", j+1)
-				fmt.Printf("pt 1 answer: %d\n", grids[j].score(calls[i]))
-				return nil
+	fmt.Printf("Grid %d has won!\n", w.Board)
+	fmt.Printf("pt 1 answer: %d\n", w.Score)
+}
+
+func part2(timeline []win) {
+	w, ok := last(timeline)
+	if !ok {
+		return
+	}
+
+	fmt.Printf("pt 2 answer: %d\n", w.Score)
+}
+
+// game holds everything parsed from the input
+type game struct {
+	calls []int
+	grids []grid
+	index callIndex
+}
+
+// clone copies the calls and boards so the copy can be played on its own, the
+// index is only read so it is shared. marks are copied by marking them again
+// so the counts in the meta row and column match
+func (g game) clone() game {
+	grids := make([]grid, len(g.grids))
+	for i := range g.grids {
+		src := &g.grids[i]
+		rows := make([][]cell, src.rows)
+		for r := range rows {
+			rows[r] = make([]cell, src.cols)
+			for c := range rows[r] {
+				rows[r][c] = cell{num: src.cells[r][c].num}
+			}
+		}
+		grids[i] = newGrid(rows)
+		for r := 0; r < src.rows; r++ {
+			for c := 0; c < src.cols; c++ {
+				if src.cells[r][c].marked {
+					grids[i].mark(r, c)
+				}
 			}
 		}
 	}
 
-	return nil
+	return game{
+		calls: append([]int(nil), g.calls...),
+		grids: grids,
+		index: g.index,
+	}
 }
 
-func part2() error {
+func getGame(input string) (game, error) {
 	s, err := inputScanner(input)
 	if err != nil {
-		log.Fatalf("unable to get input scanner: %v", err)
+		return game{}, fmt.Errorf("unable to get input scanner: %w", err)
 	}
 
 	calls, err := getCallouts(s)
 	if err != nil {
-		return fmt.Errorf("unable to get calls: %w", err)
+		return game{}, fmt.Errorf("unable to get calls: %w", err)
 	}
 
 	grids, err := getGrids(s)
 	if err != nil {
-		return fmt.Errorf("unable to get grids: %w", err)
+		return game{}, fmt.Errorf("unable to get grids: %w", err)
 	}
 
-	var (
-		lastGrid *grid
-		lastCall int
-		wins     int
-	)
-findLast:
-	for i := range calls {
-		n := calls[i]
-		for j := 0; j < len(grids); j++ {
-			grids[j].markCall(n)
-			if grids[j].hasWon() {
-				lastGrid = &grids[j]
-				lastCall = n
-				wins++
-				switch len(grids) {
-				case 1:
-					break findLast
-				default:
-					grids = append(grids[:j], grids[j+1:]...)
-					j--
-				}
-			}
-		}
+	if err := s.Err(); err != nil {
+		return game{}, fmt.Errorf("encountered error while scanning: %w", err)
 	}
 
-	if lastGrid != nil {
-		fmt.Printf("pt 2 answer: %d\n", lastGrid.score(lastCall))
+	return game{
+		calls: calls,
+		grids: grids,
+		index: newCallIndex(grids),
+	}, nil
+}
+
+// inputError points at where in the input something is wrong. board is 1
+// based and left at 0 when the line isn't part of a board
+type inputError struct {
+	line  int
+	board int
+	msg   string
+}
+
+func (e *inputError) Error() string {
+	if e.board == 0 {
+		return fmt.Sprintf("line %d: %s", e.line, e.msg)
 	}
 
-	return nil
+	return fmt.Sprintf("line %d, board %d: %s", e.line, e.board, e.msg)
 }
 
+// boards are separated by blank lines, the dimensions are inferred from the
+// first board: the # of rows before the next blank line and the # of numbers
+// in its first row. Every other board has to have the same dimensions and a
+// number can only be on a board once. The calls are expected to have taken up
+// the first line.
 func getGrids(s *bufio.Scanner) ([]grid, error) {
-	var grids []grid
-	var rows int
-	var g grid
-	for s.Scan() {
-		// reset
-		if rows == 5 {
-			g[metaCellIdx] = metaRow()
-			grids = append(grids, g)
-			rows = 0
-			g = grid{}
+	var (
+		grids []grid
+		rows  [][]cell
+		seen  map[int]int
+		start int
+		width int
+		// height is only known once the first board is done
+		height int
+	)
+	done := func() error {
+		if height == 0 {
+			height = len(rows)
+		}
+		if len(rows) != height {
+			return &inputError{
+				line:  start,
+				board: len(grids) + 1,
+				msg:   fmt.Sprintf("expected %d rows, got %d", height, len(rows)),
+			}
 		}
+		grids = append(grids, newGrid(rows))
+		rows = nil
+		return nil
+	}
 
-		l := s.Text()
+	line := 1
+	for s.Scan() {
+		line++
+		l := strings.TrimSpace(s.Text())
+		// reset
 		if l == "" {
+			if len(rows) > 0 {
+				if err := done(); err != nil {
+					return nil, err
+				}
+			}
 			continue
 		}
-		row, err := gridRow(strings.TrimSpace(l))
+
+		if len(rows) == 0 {
+			start = line
+			seen = make(map[int]int)
+		}
+
+		row, err := gridRow(l)
 		if err != nil {
-			return nil, fmt.Errorf("unable to get grid row: %w", err)
+			return nil, &inputError{line: line, board: len(grids) + 1, msg: err.Error()}
+		}
+		if width == 0 {
+			width = len(row)
+		}
+		if len(row) != width {
+			return nil, &inputError{
+				line:  line,
+				board: len(grids) + 1,
+				msg:   fmt.Sprintf("expected %d numbers in row, got %d", width, len(row)),
+			}
+		}
+		if height > 0 && len(rows) == height {
+			return nil, &inputError{
+				line:  line,
+				board: len(grids) + 1,
+				msg:   fmt.Sprintf("expected %d rows, got more", height),
+			}
+		}
+		for i := range row {
+			if prev, ok := seen[row[i].num]; ok {
+				return nil, &inputError{
+					line:  line,
+					board: len(grids) + 1,
+					msg:   fmt.Sprintf("%d is already on the board on line %d", row[i].num, prev),
+				}
+			}
+			seen[row[i].num] = line
 		}
-		g[rows] = row
-		rows++
+		rows = append(rows, row)
 	}
 
-	if rows == 5 {
-		grids = append(grids, g)
+	if len(rows) > 0 {
+		if err := done(); err != nil {
+			return nil, err
+		}
 	}
 
 	return grids, nil
 }
 
+// checkCalls warns about calls that can't mark anything since they aren't on
+// any of the boards
+func checkCalls(g game) []*inputError {
+	var warnings []*inputError
+	for i, n := range g.calls {
+		if len(g.index[n]) == 0 {
+			warnings = append(warnings, &inputError{
+				line: 1,
+				msg:  fmt.Sprintf("call %d (%d) is not on any board", i, n),
+			})
+		}
+	}
+
+	return warnings
+}
+
 func getCallouts(s *bufio.Scanner) ([]int, error) {
 	var calls []int
 	if s.Scan() {
@@ -196,7 +427,7 @@ func getCallouts(s *bufio.Scanner) ([]int, error) {
 			}
 			n, err := strconv.Atoi(strs[i])
 			if err != nil {
-				return nil, fmt.Errorf("expected a number: %v", err)
+				return nil, &inputError{line: 1, msg: fmt.Sprintf("expected a number: %v", err)}
 			}
 			calls = append(calls, n)
 		}
@@ -205,30 +436,27 @@ func getCallouts(s *bufio.Scanner) ([]int, error) {
 	return calls, nil
 }
 
-func metaRow() [6]cell {
-	return [6]cell{{meta: true}, {meta: true}, {meta: true}, {meta: true}, {meta: true}, {meta: true}}
+func metaRow(n int) []cell {
+	row := make([]cell, n)
+	for i := range row {
+		row[i] = cell{meta: true}
+	}
+
+	return row
 }
 
-func gridRow(s string) ([6]cell, error) {
-	nums := strings.Split(s, " ")
+func gridRow(s string) ([]cell, error) {
+	nums := strings.Fields(s)
 
-	var cells [6]cell
-	var ptr int
+	cells := make([]cell, 0, len(nums))
 	for i := range nums {
-		if nums[i] == "" {
-			continue
-		}
 		n, err := strconv.Atoi(nums[i])
 		if err != nil {
-			return [6]cell{}, fmt.Errorf("unable to convert to number: %w", err)
+			return nil, fmt.Errorf("unable to convert to number: %w", err)
 		}
-		cells[ptr] = cell{num: n}
-		ptr++
+		cells = append(cells, cell{num: n})
 	}
 
-	// add one more for the meta cell
-	cells[metaCellIdx] = cell{meta: true}
-
 	return cells, nil
 }
 
@@ -243,5 +471,9 @@ func inputScanner(input string) (*bufio.Scanner, error) {
 		return nil, fmt.Errorf("unable to open file: %w", err)
 	}
 
-	return bufio.NewScanner(f), nil
+	s := bufio.NewScanner(f)
+	// generated games can have calls well past the default line length
+	s.Buffer(nil, 16*1024*1024)
+
+	return s, nil
 }
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	played := markedGrid("xxx/xx./xxx")
+	g := game{calls: []int{1, 2}, grids: []grid{played}}
+
+	c := g.clone()
+	b := &c.grids[0]
+	if !linesRule(b) || blackoutRule(b) {
+		t.Fatalf("clone of a board with full rows lost its counts")
+	}
+
+	// the last cell finishes the blackout on the clone only
+	b.mark(1, 2)
+	if !blackoutRule(b) {
+		t.Errorf("clone didn't black out after its last mark")
+	}
+	if blackoutRule(&g.grids[0]) || g.grids[0].cells[1][2].marked {
+		t.Errorf("marking the clone changed the original")
+	}
+
+	c.calls[0] = 9
+	if g.calls[0] != 1 {
+		t.Errorf("changing the clone's calls changed the original")
+	}
+}
+
+func TestGetGrids(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		// boards is the # of boards read when there's no error
+		boards int
+		err    *inputError
+	}{
+		{name: "two boards", input: "1,2\n\n1 2\n3 4\n\n 5  6\n 7  8\n", boards: 2},
+		{name: "extra blank lines", input: "1\n\n\n1 2\n3 4\n\n\n5 6\n7 8\n\n", boards: 2},
+		{name: "short row", input: "1\n\n1 2\n3\n", err: &inputError{line: 4, board: 1, msg: "expected 2 numbers in row, got 1"}},
+		{name: "long row", input: "1\n\n1 2\n3 4\n\n5 6\n7 8 9\n", err: &inputError{line: 7, board: 2, msg: "expected 2 numbers in row, got 3"}},
+		{name: "too few rows", input: "1\n\n1 2\n3 4\n\n5 6\n\n7 8\n9 10\n", err: &inputError{line: 6, board: 2, msg: "expected 2 rows, got 1"}},
+		{name: "too many rows", input: "1\n\n1 2\n3 4\n\n5 6\n7 8\n9 10\n", err: &inputError{line: 8, board: 2, msg: "expected 2 rows, got more"}},
+		{name: "trailing partial board", input: "1\n\n1 2\n3 4\n\n5 6\n7 8\n\n9 10\n", err: &inputError{line: 9, board: 3, msg: "expected 2 rows, got 1"}},
+		{name: "duplicate", input: "1\n\n1 2\n3 1\n", err: &inputError{line: 4, board: 1, msg: "1 is already on the board on line 3"}},
+		{name: "same number on two boards", input: "1\n\n1 2\n3 4\n\n1 2\n3 4\n", boards: 2},
+		{name: "not a number", input: "1\n\n1 2\n3 x\n", err: &inputError{line: 4, board: 1}},
+		{name: "bad call", input: "1,x\n\n1 2\n3 4\n", err: &inputError{line: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := bufio.NewScanner(strings.NewReader(tt.input))
+			grids, err := func() ([]grid, error) {
+				if _, err := getCallouts(s); err != nil {
+					return nil, err
+				}
+				return getGrids(s)
+			}()
+
+			if tt.err == nil {
+				if err != nil {
+					t.Fatalf("unable to get grids: %v", err)
+				}
+				if len(grids) != tt.boards {
+					t.Fatalf("got %d boards, want %d", len(grids), tt.boards)
+				}
+				return
+			}
+
+			var got *inputError
+			if !errors.As(err, &got) {
+				t.Fatalf("got error %v, want an input error", err)
+			}
+			if got.line != tt.err.line || got.board != tt.err.board {
+				t.Errorf("got line %d, board %d, want line %d, board %d", got.line, got.board, tt.err.line, tt.err.board)
+			}
+			if tt.err.msg != "" && got.msg != tt.err.msg {
+				t.Errorf("got %q, want %q", got.msg, tt.err.msg)
+			}
+		})
+	}
+}
+
+func TestCheckCalls(t *testing.T) {
+	s := bufio.NewScanner(strings.NewReader("1,99,2,7\n\n1 2\n3 4\n"))
+	calls, err := getCallouts(s)
+	if err != nil {
+		t.Fatalf("unable to get calls: %v", err)
+	}
+	grids, err := getGrids(s)
+	if err != nil {
+		t.Fatalf("unable to get grids: %v", err)
+	}
+
+	warnings := checkCalls(game{calls: calls, grids: grids, index: newCallIndex(grids)})
+	want := []string{"call 1 (99) is not on any board", "call 3 (7) is not on any board"}
+	if len(warnings) != len(want) {
+		t.Fatalf("got %d warnings, want %d", len(warnings), len(want))
+	}
+	for i := range want {
+		if warnings[i].line != 1 || warnings[i].board != 0 || warnings[i].msg != want[i] {
+			t.Errorf("warning %d is %v, want line 1: %s", i, warnings[i], want[i])
+		}
+	}
+}
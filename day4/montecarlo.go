@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"text/tabwriter"
+)
+
+// estimate is how often each board won first and last over a number of
+// shuffled games
+type estimate struct {
+	trials int
+	// decided is the # of trials in which at least one board won
+	decided int
+	first   []float64
+	last    []float64
+	// calls is the expected # of calls until the first win
+	calls float64
+}
+
+// trial is the outcome of a single shuffled game. boards that win on the same
+// call share the first/last place
+type trial struct {
+	firstCall int
+	first     []int
+	last      []int
+}
+
+// monteCarlo replays the game with the calls shuffled. Each trial gets its own
+// rng seeded off of the trial # so the estimate only depends on the seed and
+// not on how the trials are spread over the workers.
+func monteCarlo(g game, rules winRules, trials, workers int, seed int64) estimate {
+	results := make([]trial, trials)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// every worker plays on its own copy of the boards
+			local := g.clone()
+			for t := range jobs {
+				rng := rand.New(rand.NewSource(seed + int64(t)))
+				copy(local.calls, g.calls)
+				rng.Shuffle(len(local.calls), func(i, j int) {
+					local.calls[i], local.calls[j] = local.calls[j], local.calls[i]
+				})
+				resetGrids(local.grids)
+				results[t] = getTrial(play(local, rules))
+			}
+		}()
+	}
+
+	for t := 0; t < trials; t++ {
+		jobs <- t
+	}
+	close(jobs)
+	wg.Wait()
+
+	e := estimate{
+		trials: trials,
+		first:  make([]float64, len(g.grids)),
+		last:   make([]float64, len(g.grids)),
+	}
+	var calls int
+	for _, r := range results {
+		if r.firstCall < 0 {
+			continue
+		}
+
+		e.decided++
+		calls += r.firstCall + 1
+		for _, b := range r.first {
+			e.first[b] += 1 / float64(len(r.first))
+		}
+		for _, b := range r.last {
+			e.last[b] += 1 / float64(len(r.last))
+		}
+	}
+
+	if e.decided == 0 {
+		return e
+	}
+
+	for i := range e.first {
+		e.first[i] /= float64(e.decided)
+		e.last[i] /= float64(e.decided)
+	}
+	e.calls = float64(calls) / float64(e.decided)
+
+	return e
+}
+
+func getTrial(timeline []win) trial {
+	w, ok := first(timeline)
+	if !ok {
+		return trial{firstCall: -1}
+	}
+
+	t := trial{firstCall: w.Call}
+	for i := 0; i < len(timeline) && timeline[i].Call == w.Call; i++ {
+		t.first = append(t.first, timeline[i].Board-1)
+	}
+
+	l, _ := last(timeline)
+	for i := l.Place - 1; i >= 0 && timeline[i].Call == l.Call; i-- {
+		t.last = append(t.last, timeline[i].Board-1)
+	}
+
+	return t
+}
+
+func writeEstimate(w io.Writer, e estimate) error {
+	fmt.Fprintf(w, "trials: %d, decided: %d\n", e.trials, e.decided)
+	fmt.Fprintf(w, "expected calls until first win: %.2f\n", e.calls)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "board\tP(first)\tP(last)\t")
+	for i := range e.first {
+		fmt.Fprintf(tw, "%d\t%.4f\t%.4f\t\n", i+1, e.first[i], e.last[i])
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("unable to write estimate: %w", err)
+	}
+
+	return nil
+}
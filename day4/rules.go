@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// winRule reports whether a board has won under a certain pattern
+type winRule func(g *grid) bool
+
+// winRules holds the rules selected for a run, a board wins as soon as any
+// one of them matches
+type winRules []winRule
+
+func (r winRules) hasWon(g *grid) bool {
+	for i := range r {
+		if r[i](g) {
+			return true
+		}
+	}
+	return false
+}
+
+// getWinRules builds the rules from a comma separated list of names. the mask
+// rule needs the path to a mask file
+func getWinRules(names string, maskPath string) (winRules, error) {
+	var rules winRules
+	for _, name := range strings.Split(names, ",") {
+		switch strings.TrimSpace(name) {
+		case "lines":
+			rules = append(rules, linesRule)
+		case "diagonals":
+			rules = append(rules, diagonalsRule)
+		case "corners":
+			rules = append(rules, cornersRule)
+		case "x":
+			rules = append(rules, xRule)
+		case "blackout":
+			rules = append(rules, blackoutRule)
+		case "mask":
+			if maskPath == "" {
+				return nil, fmt.Errorf("mask rule needs a mask file")
+			}
+			m, err := getMask(maskPath)
+			if err != nil {
+				return nil, fmt.Errorf("unable to get mask: %w", err)
+			}
+			rules = append(rules, maskRule(m))
+		case "":
+			continue
+		default:
+			return nil, fmt.Errorf("unsupported win rule: %s", name)
+		}
+	}
+
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("no win rules selected")
+	}
+
+	return rules, nil
+}
+
+// any full row or column, the rule from the puzzle
+func linesRule(g *grid) bool {
+	return g.hasWon()
+}
+
+// either diagonal, only square boards have them
+func diagonalsRule(g *grid) bool {
+	if g.rows != g.cols {
+		return false
+	}
+
+	down, up := true, true
+	for i := 0; i < g.rows; i++ {
+		down = down && g.cells[i][i].marked
+		up = up && g.cells[g.rows-1-i][i].marked
+	}
+
+	return down || up
+}
+
+func cornersRule(g *grid) bool {
+	last := g.rows - 1
+	end := g.cols - 1
+	return g.cells[0][0].marked && g.cells[0][end].marked &&
+		g.cells[last][0].marked && g.cells[last][end].marked
+}
+
+// both diagonals, only square boards have them
+func xRule(g *grid) bool {
+	if g.rows != g.cols {
+		return false
+	}
+
+	for i := 0; i < g.rows; i++ {
+		if !g.cells[i][i].marked || !g.cells[g.rows-1-i][i].marked {
+			return false
+		}
+	}
+
+	return true
+}
+
+// every number marked, we can just check the meta column of each row
+func blackoutRule(g *grid) bool {
+	for i := 0; i < g.rows; i++ {
+		if g.cells[i][g.cols].num != g.cols {
+			return false
+		}
+	}
+
+	return true
+}
+
+// maskRule wins when every cell set in the mask is marked. boards of a
+// different size than the mask can never win with it
+func maskRule(mask [][]bool) winRule {
+	return func(g *grid) bool {
+		if len(mask) != g.rows || len(mask[0]) != g.cols {
+			return false
+		}
+
+		for i := range mask {
+			for j := range mask[i] {
+				if mask[i][j] && !g.cells[i][j].marked {
+					return false
+				}
+			}
+		}
+
+		return true
+	}
+}
+
+// getMask reads a mask laid out like a board in which `x` is a cell that has
+// to be marked and `.` is one that doesn't, e.g.
+//
+//	x . . . x
+//	. x . x .
+//	. . x . .
+func getMask(path string) ([][]bool, error) {
+	s, err := inputScanner(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get mask scanner: %w", err)
+	}
+
+	var mask [][]bool
+	for s.Scan() {
+		l := strings.TrimSpace(s.Text())
+		if l == "" {
+			continue
+		}
+
+		var row []bool
+		for _, c := range strings.ReplaceAll(l, " ", "") {
+			switch c {
+			case 'x', 'X':
+				row = append(row, true)
+			case '.':
+				row = append(row, false)
+			default:
+				return nil, fmt.Errorf("unexpected mask character: %q", c)
+			}
+		}
+
+		if len(mask) > 0 && len(row) != len(mask[0]) {
+			return nil, fmt.Errorf("expected %d cells in mask row, got %d", len(mask[0]), len(row))
+		}
+		mask = append(mask, row)
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("encountered error while scanning mask: %w", err)
+	}
+
+	if len(mask) == 0 {
+		return nil, fmt.Errorf("empty mask")
+	}
+
+	return mask, nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// markedGrid builds a board laid out like a mask, `x` cells are marked and
+// `.` cells aren't, rows are split by /
+func markedGrid(layout string) grid {
+	var rows [][]cell
+	var marks [][2]int
+	for i, l := range strings.Split(layout, "/") {
+		var row []cell
+		for j, c := range strings.ReplaceAll(l, " ", "") {
+			row = append(row, cell{num: len(rows)*100 + j})
+			if c == 'x' {
+				marks = append(marks, [2]int{i, j})
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	g := newGrid(rows)
+	for _, m := range marks {
+		g.mark(m[0], m[1])
+	}
+
+	return g
+}
+
+func TestWinRules(t *testing.T) {
+	mask := [][]bool{
+		{true, false, true},
+		{false, true, false},
+		{true, false, true},
+	}
+
+	tests := []struct {
+		name   string
+		layout string
+		rule   winRule
+		want   bool
+	}{
+		{name: "row", layout: "xxx/.../...", rule: linesRule, want: true},
+		{name: "column", layout: ".x./.x./.x.", rule: linesRule, want: true},
+		{name: "no line", layout: "xx./..x/x..", rule: linesRule, want: false},
+		{name: "down diagonal", layout: "x../.x./..x", rule: diagonalsRule, want: true},
+		{name: "up diagonal", layout: "..x/.x./x..", rule: diagonalsRule, want: true},
+		{name: "broken diagonal", layout: "x../.../..x", rule: diagonalsRule, want: false},
+		{name: "diagonal on a non-square board", layout: "x.../.x../..x.", rule: diagonalsRule, want: false},
+		{name: "corners", layout: "x.x/.../x.x", rule: cornersRule, want: true},
+		{name: "three corners", layout: "x.x/.../x..", rule: cornersRule, want: false},
+		{name: "corners on a non-square board", layout: "x..x/..../x..x", rule: cornersRule, want: true},
+		{name: "x", layout: "x.x/.x./x.x", rule: xRule, want: true},
+		{name: "one diagonal isn't an x", layout: "x../.x./..x", rule: xRule, want: false},
+		{name: "x on a non-square board", layout: "xxxx/xxxx/xxxx", rule: xRule, want: false},
+		{name: "blackout", layout: "xxx/xxx/xxx", rule: blackoutRule, want: true},
+		{name: "all but one", layout: "xxx/x.x/xxx", rule: blackoutRule, want: false},
+		{name: "blackout on a non-square board", layout: "xx/xx/xx", rule: blackoutRule, want: true},
+		{name: "mask", layout: "x.x/.x./x.x", rule: maskRule(mask), want: true},
+		{name: "more than the mask", layout: "xxx/xx./x.x", rule: maskRule(mask), want: true},
+		{name: "mask missing a cell", layout: "x.x/.../x.x", rule: maskRule(mask), want: false},
+		{name: "mask of another size", layout: "xxxx/xxxx/xxxx/xxxx", rule: maskRule(mask), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := markedGrid(tt.layout)
+			if got := tt.rule(&g); got != tt.want {
+				t.Errorf("%s: got %t, want %t", tt.layout, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetWinRules(t *testing.T) {
+	dir := t.TempDir()
+	maskPath := filepath.Join(dir, "mask.txt")
+	if err := ioutil.WriteFile(maskPath, []byte("x . x\n. x .\n\nx . x\n"), 0o644); err != nil {
+		t.Fatalf("unable to write mask: %v", err)
+	}
+
+	rules, err := getWinRules("lines, diagonals,corners,x,blackout,mask", maskPath)
+	if err != nil {
+		t.Fatalf("unable to get rules: %v", err)
+	}
+	if len(rules) != 6 {
+		t.Fatalf("got %d rules, want 6", len(rules))
+	}
+
+	// the mask is the x, so an x wins and nothing else does
+	g := markedGrid("x.x/.x./x.x")
+	if !(winRules{rules[5]}).hasWon(&g) {
+		t.Errorf("mask rule didn't match its own pattern")
+	}
+	g = markedGrid("xx./.x./x.x")
+	if !rules.hasWon(&g) {
+		t.Errorf("a diagonal didn't win with every rule on")
+	}
+	g = markedGrid("xx./.../..x")
+	if rules.hasWon(&g) {
+		t.Errorf("won without matching any rule")
+	}
+
+	for _, bad := range []struct{ names, mask string }{
+		{names: "lines,stripes"},
+		{names: "mask"},
+		{names: " , "},
+		{names: "mask", mask: filepath.Join(dir, "missing.txt")},
+	} {
+		if _, err := getWinRules(bad.names, bad.mask); err == nil {
+			t.Errorf("%q with mask %q: expected an error", bad.names, bad.mask)
+		}
+	}
+}
+
+func TestGetMask(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  [][]bool
+		err   string
+	}{
+		{name: "spaced", input: "x . x\n. X .\n", want: [][]bool{{true, false, true}, {false, true, false}}},
+		{name: "packed", input: "\nx.\n.x\n\n", want: [][]bool{{true, false}, {false, true}}},
+		{name: "bad character", input: "x o\n", err: "unexpected mask character"},
+		{name: "ragged", input: "x.x\nx.\n", err: "expected 3 cells"},
+		{name: "empty", input: "\n\n", err: "empty mask"},
+	}
+
+	dir := t.TempDir()
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, strings.Repeat("m", i+1))
+			if err := ioutil.WriteFile(path, []byte(tt.input), 0o644); err != nil {
+				t.Fatalf("unable to write mask: %v", err)
+			}
+
+			got, err := getMask(path)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unable to get mask: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for r := range got {
+				for c := range got[r] {
+					if got[r][c] != tt.want[r][c] {
+						t.Fatalf("got %v, want %v", got, tt.want)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// bingoServer runs a live game over http. Players register boards, a caller
+// announces numbers and every mark and win is pushed to the subscribers of
+// /events as server-sent events. It's a plain http.Handler so it can be
+// driven in process through httptest without any network.
+//
+//	POST /players     {"name": "squid", "board": [[1, 2], [3, 4]]}
+//	GET  /players/:id
+//	POST /calls       {"number": 7}, an empty body draws a number
+//	GET  /events
+type bingoServer struct {
+	mu      sync.Mutex
+	rules   winRules
+	rng     *rand.Rand
+	rows    int
+	cols    int
+	max     int
+	players []*player
+	calls   []int
+	called  map[int]bool
+	// drawable is the # of numbers in [0, max) that haven't been called
+	drawable int
+	subs     map[chan event]struct{}
+	mux      *http.ServeMux
+}
+
+type player struct {
+	id    int
+	name  string
+	board grid
+	won   bool
+	score int
+}
+
+// event is pushed to every subscriber. a call is followed by the marks and
+// wins it caused, a join by the marks and win of the numbers called before
+// the player joined
+type event struct {
+	Type   string `json:"type"`
+	Number int    `json:"number"`
+	Player int    `json:"player,omitempty"`
+	// Cell is the row and column of a mark
+	Cell  *[2]int `json:"cell,omitempty"`
+	Score int     `json:"score,omitempty"`
+}
+
+// playerState is how a player is shown over the wire
+type playerState struct {
+	ID     int      `json:"id"`
+	Name   string   `json:"name"`
+	Board  [][]int  `json:"board"`
+	Marked [][]bool `json:"marked"`
+	Won    bool     `json:"won"`
+	Score  int      `json:"score,omitempty"`
+}
+
+// generated boards are rows x cols with numbers drawn from [0, max), which is
+// also the range the server draws calls from
+func newBingoServer(rules winRules, rows, cols, max int, seed int64) (*bingoServer, error) {
+	if rows < 1 || cols < 1 || rows*cols > max {
+		return nil, fmt.Errorf("unable to fill %dx%d boards with numbers below %d", rows, cols, max)
+	}
+
+	b := &bingoServer{
+		rules:    rules,
+		rng:      rand.New(rand.NewSource(seed)),
+		rows:     rows,
+		cols:     cols,
+		max:      max,
+		called:   make(map[int]bool),
+		drawable: max,
+		subs:     make(map[chan event]struct{}),
+		mux:      http.NewServeMux(),
+	}
+	b.mux.HandleFunc("/players", b.handleRegister)
+	b.mux.HandleFunc("/players/", b.handlePlayer)
+	b.mux.HandleFunc("/calls", b.handleCall)
+	b.mux.HandleFunc("/events", b.handleEvents)
+
+	return b, nil
+}
+
+func (b *bingoServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mux.ServeHTTP(w, r)
+}
+
+func (b *bingoServer) handleRegister(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Name  string  `json:"name"`
+		Board [][]int `json:"board"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, fmt.Sprintf("unable to decode request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var g grid
+	if req.Board == nil {
+		g = randomGrid(b.rng, b.rows, b.cols, b.max)
+	} else {
+		var err error
+		if g, err = boardGrid(req.Board); err != nil {
+			http.Error(w, fmt.Sprintf("invalid board: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	p := &player{id: len(b.players) + 1, name: req.Name, board: g}
+	b.players = append(b.players, p)
+	b.publish(event{Type: "join", Player: p.id})
+
+	// numbers called before joining still count, and subscribers see the
+	// marks and any win they cause same as for a live call
+	for _, n := range b.calls {
+		for _, e := range b.markPlayer(p, n) {
+			b.publish(e)
+		}
+	}
+
+	writeJSON(w, http.StatusCreated, p.state())
+}
+
+func (b *bingoServer) handlePlayer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/players/"))
+	if err != nil {
+		http.Error(w, "expected a player id", http.StatusBadRequest)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if id < 1 || id > len(b.players) {
+		http.Error(w, "player not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, b.players[id-1].state())
+}
+
+func (b *bingoServer) handleCall(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Number *int `json:"number"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, fmt.Sprintf("unable to decode request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var n int
+	switch {
+	case req.Number != nil:
+		n = *req.Number
+	case b.drawable == 0:
+		http.Error(w, "every number has been called", http.StatusConflict)
+		return
+	default:
+		n = b.rng.Intn(b.max)
+		for b.called[n] {
+			n = b.rng.Intn(b.max)
+		}
+	}
+
+	if b.called[n] {
+		http.Error(w, fmt.Sprintf("%d has already been called", n), http.StatusConflict)
+		return
+	}
+
+	events := b.call(n)
+	writeJSON(w, http.StatusOK, events)
+}
+
+// call marks the number on every board that hasn't won yet and pushes the
+// events to the subscribers. b.mu must be held
+func (b *bingoServer) call(n int) []event {
+	b.called[n] = true
+	b.calls = append(b.calls, n)
+	// numbers called by hand can be outside of the range numbers are
+	// drawn from
+	if n >= 0 && n < b.max {
+		b.drawable--
+	}
+
+	events := []event{{Type: "call", Number: n}}
+	for _, p := range b.players {
+		events = append(events, b.markPlayer(p, n)...)
+	}
+
+	for _, e := range events {
+		b.publish(e)
+	}
+
+	return events
+}
+
+func (b *bingoServer) markPlayer(p *player, n int) []event {
+	if p.won {
+		return nil
+	}
+
+	var events []event
+	for i := 0; i < p.board.rows; i++ {
+		for j := 0; j < p.board.cols; j++ {
+			if p.board.cells[i][j].num != n {
+				continue
+			}
+			p.board.mark(i, j)
+			events = append(events, event{Type: "mark", Number: n, Player: p.id, Cell: &[2]int{i, j}})
+		}
+	}
+
+	if len(events) > 0 && b.rules.hasWon(&p.board) {
+		p.won = true
+		p.score = p.board.score(n)
+		events = append(events, event{Type: "win", Number: n, Player: p.id, Score: p.score})
+	}
+
+	return events
+}
+
+// publish drops subscribers that can't keep up rather than stalling the
+// game. b.mu must be held
+func (b *bingoServer) publish(e event) {
+	for sub := range b.subs {
+		select {
+		case sub <- e:
+		default:
+			delete(b.subs, sub)
+			close(sub)
+		}
+	}
+}
+
+func (b *bingoServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	sub := make(chan event, 64)
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		if _, ok := b.subs[sub]; ok {
+			delete(b.subs, sub)
+			close(sub)
+		}
+		b.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-sub:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (p *player) state() playerState {
+	s := playerState{
+		ID:     p.id,
+		Name:   p.name,
+		Board:  make([][]int, p.board.rows),
+		Marked: make([][]bool, p.board.rows),
+		Won:    p.won,
+		Score:  p.score,
+	}
+	for i := range s.Board {
+		s.Board[i] = make([]int, p.board.cols)
+		s.Marked[i] = make([]bool, p.board.cols)
+		for j := range s.Board[i] {
+			s.Board[i][j] = p.board.cells[i][j].num
+			s.Marked[i][j] = p.board.cells[i][j].marked
+		}
+	}
+
+	return s
+}
+
+// boardGrid checks a board sent by a player, rows must all be the same length
+// and a number can only be on the board once
+func boardGrid(board [][]int) (grid, error) {
+	if len(board) == 0 || len(board[0]) == 0 {
+		return grid{}, errors.New("empty board")
+	}
+
+	seen := make(map[int]bool)
+	rows := make([][]cell, len(board))
+	for i := range board {
+		if len(board[i]) != len(board[0]) {
+			return grid{}, fmt.Errorf("expected %d numbers in row %d, got %d", len(board[0]), i+1, len(board[i]))
+		}
+		for _, n := range board[i] {
+			if seen[n] {
+				return grid{}, fmt.Errorf("duplicate number %d", n)
+			}
+			seen[n] = true
+			rows[i] = append(rows[i], cell{num: n})
+		}
+	}
+
+	return newGrid(rows), nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	// the status is already out, nothing else to do on failure
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	b, err := newBingoServer(winRules{linesRule}, 2, 2, 10, 1)
+	if err != nil {
+		t.Fatalf("unable to create server: %v", err)
+	}
+	ts := httptest.NewServer(b)
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+func post(t *testing.T, url string, body string, want int, v interface{}) {
+	t.Helper()
+
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("unable to post to %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != want {
+		t.Fatalf("post %s %s: got status %d, want %d", url, body, resp.StatusCode, want)
+	}
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("unable to decode response: %v", err)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	ts := newTestServer(t)
+
+	var p playerState
+	post(t, ts.URL+"/players", `{"name": "squid", "board": [[1, 2], [3, 4]]}`, http.StatusCreated, &p)
+	if p.ID != 1 || p.Name != "squid" || p.Board[1][0] != 3 {
+		t.Fatalf("registered %+v", p)
+	}
+
+	var random playerState
+	post(t, ts.URL+"/players", `{}`, http.StatusCreated, &random)
+	if random.ID != 2 || len(random.Board) != 2 || len(random.Board[0]) != 2 {
+		t.Fatalf("registered %+v, want a random 2x2 board", random)
+	}
+
+	post(t, ts.URL+"/players", `{"board": [[1, 1]]}`, http.StatusBadRequest, nil)
+	post(t, ts.URL+"/players", `{"board": [[1, 2], [3]]}`, http.StatusBadRequest, nil)
+
+	resp, err := http.Get(ts.URL + "/players/1")
+	if err != nil {
+		t.Fatalf("unable to get player: %v", err)
+	}
+	defer resp.Body.Close()
+	var got playerState
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("unable to decode player: %v", err)
+	}
+	if got.Name != "squid" {
+		t.Fatalf("got player %+v", got)
+	}
+
+	resp, err = http.Get(ts.URL + "/players/3")
+	if err != nil {
+		t.Fatalf("unable to get player: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("got status %d for a missing player", resp.StatusCode)
+	}
+}
+
+func TestCall(t *testing.T) {
+	ts := newTestServer(t)
+	post(t, ts.URL+"/players", `{"board": [[1, 2], [3, 4]]}`, http.StatusCreated, nil)
+
+	var events []event
+	post(t, ts.URL+"/calls", `{"number": 1}`, http.StatusOK, &events)
+	if len(events) != 2 || events[0].Type != "call" || events[1].Type != "mark" {
+		t.Fatalf("calling 1 gave %+v", events)
+	}
+
+	post(t, ts.URL+"/calls", `{"number": 2}`, http.StatusOK, &events)
+	if len(events) != 3 || events[2].Type != "win" || events[2].Score != (3+4)*2 {
+		t.Fatalf("calling 2 gave %+v, want a win", events)
+	}
+
+	// a board that has won doesn't get marked any more
+	post(t, ts.URL+"/calls", `{"number": 3}`, http.StatusOK, &events)
+	if len(events) != 1 {
+		t.Fatalf("calling 3 gave %+v", events)
+	}
+
+	// a drawn number is one that hasn't been called
+	post(t, ts.URL+"/calls", ``, http.StatusOK, &events)
+	if n := events[0].Number; n == 1 || n == 2 || n == 3 || n < 0 || n >= 10 {
+		t.Fatalf("drew %d", n)
+	}
+}
+
+func TestDuplicateCall(t *testing.T) {
+	ts := newTestServer(t)
+
+	post(t, ts.URL+"/calls", `{"number": 5}`, http.StatusOK, nil)
+	post(t, ts.URL+"/calls", `{"number": 5}`, http.StatusConflict, nil)
+}
+
+func TestDrawAfterOutOfRangeCall(t *testing.T) {
+	ts := newTestServer(t)
+
+	// 50 isn't one of the numbers that can be drawn, so every one of them
+	// still can be
+	post(t, ts.URL+"/calls", `{"number": 50}`, http.StatusOK, nil)
+	seen := make(map[int]bool)
+	for i := 0; i < 10; i++ {
+		var events []event
+		post(t, ts.URL+"/calls", ``, http.StatusOK, &events)
+		seen[events[0].Number] = true
+	}
+	if len(seen) != 10 {
+		t.Fatalf("drew %v, want every number below 10", seen)
+	}
+
+	post(t, ts.URL+"/calls", ``, http.StatusConflict, nil)
+}
+
+func TestBoardsTooBig(t *testing.T) {
+	if _, err := newBingoServer(winRules{linesRule}, 5, 5, 10, 1); err == nil {
+		t.Fatalf("expected an error for 5x5 boards with numbers below 10")
+	}
+}
+
+// readEvents reads server-sent events off of the stream until it has n
+func readEvents(t *testing.T, r *bufio.Reader, n int) []event {
+	t.Helper()
+
+	var events []event
+	for len(events) < n {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			t.Fatalf("unable to read event: %v", err)
+		}
+		if !bytes.HasPrefix(line, []byte("data: ")) {
+			continue
+		}
+		var e event
+		if err := json.Unmarshal(bytes.TrimPrefix(line, []byte("data: ")), &e); err != nil {
+			t.Fatalf("unable to decode event %s: %v", line, err)
+		}
+		events = append(events, e)
+	}
+
+	return events
+}
+
+func TestEvents(t *testing.T) {
+	ts := newTestServer(t)
+
+	resp, err := http.Get(ts.URL + "/events")
+	if err != nil {
+		t.Fatalf("unable to subscribe: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("got content type %s", ct)
+	}
+	stream := bufio.NewReader(resp.Body)
+
+	post(t, ts.URL+"/players", `{"board": [[1, 2], [3, 4]]}`, http.StatusCreated, nil)
+	post(t, ts.URL+"/calls", `{"number": 1}`, http.StatusOK, nil)
+	post(t, ts.URL+"/calls", `{"number": 3}`, http.StatusOK, nil)
+
+	got := readEvents(t, stream, 6)
+	want := []string{"join", "call", "mark", "call", "mark", "win"}
+	for i := range want {
+		if got[i].Type != want[i] {
+			t.Fatalf("event %d is %+v, want a %s", i, got[i], want[i])
+		}
+	}
+	if got[5].Player != 1 || got[5].Score != (2+4)*3 {
+		t.Fatalf("got win %+v", got[5])
+	}
+
+	// a late joiner's catch up shows up on the stream too
+	var p playerState
+	post(t, ts.URL+"/players", `{"board": [[3, 1], [5, 6]]}`, http.StatusCreated, &p)
+	if !p.Won || p.Score != (5+6)*3 {
+		t.Fatalf("late joiner %+v should have won", p)
+	}
+
+	got = readEvents(t, stream, 4)
+	want = []string{"join", "mark", "mark", "win"}
+	for i := range want {
+		if got[i].Type != want[i] || got[i].Player != 2 {
+			t.Fatalf("event %d is %+v, want a %s for player 2", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// win records when a board won. boards that never win are left with Won unset
+// and a Call of -1
+type win struct {
+	// Board is the 1 based position of the board in the input
+	Board int  `json:"board"`
+	Won   bool `json:"won"`
+	// Place is the 1 based order in which the board won
+	Place int `json:"place,omitempty"`
+	// Call is the index of the winning number in the call sequence
+	Call   int `json:"call"`
+	Number int `json:"number"`
+	Score  int `json:"score"`
+}
+
+// play runs through every call once, marking the boards that contain it and
+// haven't won yet. The timeline holds the winners in the order they won,
+// followed by the boards that never won in input order.
+func play(g game, rules winRules) []win {
+	timeline := make([]win, 0, len(g.grids))
+	won := make([]bool, len(g.grids))
+	for i, call := range g.calls {
+		if len(timeline) == len(g.grids) {
+			break
+		}
+
+		for _, loc := range g.index[call] {
+			if won[loc.board] {
+				continue
+			}
+
+			board := &g.grids[loc.board]
+			board.mark(loc.row, loc.col)
+			if rules.hasWon(board) {
+				won[loc.board] = true
+				timeline = append(timeline, win{
+					Board:  loc.board + 1,
+					Won:    true,
+					Place:  len(timeline) + 1,
+					Call:   i,
+					Number: call,
+					Score:  board.score(call),
+				})
+			}
+		}
+	}
+
+	for j := range g.grids {
+		if !won[j] {
+			timeline = append(timeline, win{Board: j + 1, Call: -1})
+		}
+	}
+
+	return timeline
+}
+
+// first and last return the first and last winner of a timeline, ok is false
+// when no board won
+func first(timeline []win) (win, bool) {
+	if len(timeline) == 0 || !timeline[0].Won {
+		return win{}, false
+	}
+
+	return timeline[0], true
+}
+
+func last(timeline []win) (win, bool) {
+	for i := len(timeline) - 1; i >= 0; i-- {
+		if timeline[i].Won {
+			return timeline[i], true
+		}
+	}
+
+	return win{}, false
+}
+
+func writeTimeline(w io.Writer, format string, timeline []win) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(timeline); err != nil {
+			return fmt.Errorf("unable to encode timeline: %w", err)
+		}
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(tw, "place\tboard\tcall\tnumber\tscore\t")
+		for _, t := range timeline {
+			if !t.Won {
+				fmt.Fprintf(tw, "-\t%d\t-\t-\t-\t\n", t.Board)
+				continue
+			}
+			fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%d\t\n", t.Place, t.Board, t.Call, t.Number, t.Score)
+		}
+		if err := tw.Flush(); err != nil {
+			return fmt.Errorf("unable to write timeline: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported timeline format: %s", format)
+	}
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+// BenchmarkBackends walks every segment of the input into each of the
+// backends
+func BenchmarkBackends(b *testing.B) {
+	segs, err := getSegments("input.txt")
+	if err != nil {
+		b.Fatalf("unable to get segments: %v", err)
+	}
+
+	for _, backend := range backends {
+		b.Run(backend, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := walkOverlaps(segs, unitRaster, backend, 2); err != nil {
+					b.Fatalf("unable to walk: %v", err)
+				}
+			}
+		})
+	}
+}
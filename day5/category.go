@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// category is a kind of line, categories are bits so they can be combined
+type category uint8
+
+const (
+	horizontals category = 1 << iota
+	verticals
+	// diagonals are any lines that aren't axis aligned
+	diagonals
+
+	allCategories = horizontals | verticals | diagonals
+)
+
+var categoryNames = []struct {
+	c    category
+	name string
+}{
+	{horizontals, "horizontal"},
+	{verticals, "vertical"},
+	{diagonals, "diagonal"},
+}
+
+// single points count as horizontal, the same as for the sweep engine
+func (s segment) category() category {
+	switch {
+	case s.from.y == s.to.y:
+		return horizontals
+	case s.from.x == s.to.x:
+		return verticals
+	default:
+		return diagonals
+	}
+}
+
+func (c category) String() string {
+	var names []string
+	for _, n := range categoryNames {
+		if c&n.c != 0 {
+			names = append(names, n.name)
+		}
+	}
+
+	return strings.Join(names, "+")
+}
+
+// getCategories parses a comma separated list like horizontal,diagonal, the
+// first letter of each is enough
+func getCategories(list string) (category, error) {
+	var c category
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		found := false
+		for _, n := range categoryNames {
+			if name == n.name || name == n.name[:1] {
+				c |= n.c
+				found = true
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unsupported category: %s", name)
+		}
+	}
+
+	if c == 0 {
+		return 0, fmt.Errorf("no categories selected")
+	}
+
+	return c, nil
+}
+
+// filterSegments keeps the segments of the given categories
+func filterSegments(segs []segment, c category) []segment {
+	var kept []segment
+	for i := range segs {
+		if segs[i].category()&c != 0 {
+			kept = append(kept, segs[i])
+		}
+	}
+
+	return kept
+}
+
+// breakdown walks the segments keeping track of the kinds of lines covering
+// each point. the overlaps are then grouped by the combination of kinds, e.g.
+// a point where a horizontal line crosses a vertical one goes under
+// horizontal+vertical while two horizontal lines sharing a point go under
+// horizontal
+func breakdown(segs []segment, r rasterizer, min int) (map[category]int, error) {
+	type cover struct {
+		n    int
+		cats category
+	}
+	seen := make(map[point]cover)
+	for i := range segs {
+		c := segs[i].category()
+		err := r(segs[i], func(p point) {
+			v := seen[p]
+			v.n++
+			v.cats |= c
+			seen[p] = v
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	counts := make(map[category]int)
+	for _, v := range seen {
+		if v.n >= min {
+			counts[v.cats]++
+		}
+	}
+
+	return counts, nil
+}
+
+func writeBreakdown(w io.Writer, counts map[category]int) error {
+	cats := make([]category, 0, len(counts))
+	for c := range counts {
+		cats = append(cats, c)
+	}
+	sort.Slice(cats, func(i, j int) bool {
+		return cats[i] < cats[j]
+	})
+
+	for _, c := range cats {
+		if _, err := fmt.Fprintf(w, "%s: %d\n", c, counts[c]); err != nil {
+			return fmt.Errorf("unable to write breakdown: %w", err)
+		}
+	}
+
+	return nil
+}
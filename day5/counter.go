@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// a dense grid of 16M points takes up 32MB
+	maxDenseArea = 1 << 24
+	// past this many points of area per point walked the grid is mostly empty
+	// and the map is the better deal
+	maxDenseSparsity = 64
+)
+
+// counter tracks the # of segments covering each point
+type counter interface {
+	inc(p point)
+	// overlaps is the # of points covered by at least min segments
+	overlaps(min int) int
+}
+
+// backends are the counters that can be asked for by name, auto picks one of
+// them
+var backends = []string{"map", "dense"}
+
+// newCounter picks the backend for walking the segments. auto goes dense
+// unless the bounding box of the segments is huge or mostly empty
+func newCounter(backend string, segs []segment) (counter, error) {
+	switch backend {
+	case "map":
+		return make(mapCounter), nil
+	case "dense":
+		b := bounds(segs)
+		if area(b) > maxDenseArea {
+			return nil, fmt.Errorf("%dx%d is too big for a dense grid", b.width(), b.height())
+		}
+		return newDenseCounter(b), nil
+	case "auto":
+		b := bounds(segs)
+		if a := area(b); a > maxDenseArea || a > maxDenseSparsity*walked(segs) {
+			return make(mapCounter), nil
+		}
+		return newDenseCounter(b), nil
+	default:
+		return nil, fmt.Errorf("unsupported backend: %s", backend)
+	}
+}
+
+type mapCounter map[point]int
+
+func (m mapCounter) inc(p point) {
+	m[p]++
+}
+
+func (m mapCounter) overlaps(min int) int {
+	var n int
+	for _, v := range m {
+		if v >= min {
+			n++
+		}
+	}
+
+	return n
+}
+
+// denseCounter lays the bounding box out row by row in a flat slice. counts
+// stop going up at the max of a uint16
+type denseCounter struct {
+	bounds window
+	cells  []uint16
+}
+
+func newDenseCounter(b window) *denseCounter {
+	return &denseCounter{
+		bounds: b,
+		cells:  make([]uint16, b.width()*b.height()),
+	}
+}
+
+func (d *denseCounter) inc(p point) {
+	i := (p.y-d.bounds.min.y)*d.bounds.width() + p.x - d.bounds.min.x
+	if d.cells[i] < math.MaxUint16 {
+		d.cells[i]++
+	}
+}
+
+func (d *denseCounter) overlaps(min int) int {
+	var n int
+	for _, v := range d.cells {
+		if int(v) >= min {
+			n++
+		}
+	}
+
+	return n
+}
+
+// bounds is the smallest window holding every segment. every raster stays
+// between the ends of a segment so the ends are all that's needed
+func bounds(segs []segment) window {
+	if len(segs) == 0 {
+		return window{}
+	}
+
+	b := window{min: segs[0].from, max: segs[0].from}
+	for _, s := range segs {
+		for _, p := range []point{s.from, s.to} {
+			if p.x < b.min.x {
+				b.min.x = p.x
+			}
+			if p.y < b.min.y {
+				b.min.y = p.y
+			}
+			if p.x > b.max.x {
+				b.max.x = p.x
+			}
+			if p.y > b.max.y {
+				b.max.y = p.y
+			}
+		}
+	}
+
+	return b
+}
+
+// area is worked out in floats so far apart coordinates can't overflow
+func area(b window) float64 {
+	return float64(b.width()) * float64(b.height())
+}
+
+// walked is about how many points walking the segments visits, the longer
+// side of each segment plus its start
+func walked(segs []segment) float64 {
+	var n float64
+	for _, s := range segs {
+		dx, dy := absSub(s.from.x, s.to.x), absSub(s.from.y, s.to.y)
+		if dy > dx {
+			dx = dy
+		}
+		n += float64(dx + 1)
+	}
+
+	return n
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+var (
+	engines = []string{"walk", "sweep"}
+	rasters = []string{"unit", "lattice", "bresenham"}
+)
+
+// thresholds from covered at all up to a few lines deep
+const maxCheckedMin = 4
+
+// # of random inputs of short segments crammed into a small area so they
+// overlap a lot
+const randomInputs = 200
+
+// TestEngines makes sure every engine, with every raster and backend, comes up
+// with the same count as the walker for both parts of the input and for
+// random inputs
+func TestEngines(t *testing.T) {
+	segs, err := getSegments("input.txt")
+	if err != nil {
+		t.Fatalf("unable to get segments: %v", err)
+	}
+
+	if err := compareEngines(filterSegments(segs, horizontals|verticals)); err != nil {
+		t.Errorf("part 1: %v", err)
+	}
+	if err := compareEngines(segs); err != nil {
+		t.Errorf("part 2: %v", err)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < randomInputs; i++ {
+		if err := compareEngines(randomSegments(rng, 1+rng.Intn(50), 20)); err != nil {
+			t.Fatalf("random input %d: %v", i, err)
+		}
+	}
+}
+
+func compareEngines(segs []segment) error {
+	for min := 1; min <= maxCheckedMin; min++ {
+		want, err := walkOverlaps(segs, unitRaster, "map", min)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range engines {
+			for _, raster := range rasters {
+				for _, backend := range backends {
+					count, err := getEngine(name, raster, backend)
+					if err != nil {
+						return err
+					}
+					got, err := count(segs, min)
+					if err != nil {
+						return fmt.Errorf("%s with %s raster and %s backend: %w", name, raster, backend, err)
+					}
+					if got != want {
+						return fmt.Errorf("%s with %s raster and %s backend counted %d points covered %d+ times, walk counted %d in %v", name, raster, backend, got, min, want, segs)
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// randomSegments only makes horizontal, vertical and diagonal segments with
+// both ends inside [0, size)
+func randomSegments(rng *rand.Rand, n, size int) []segment {
+	dirs := []slope{{1, 0}, {0, 1}, {1, 1}, {1, -1}}
+	segs := make([]segment, 0, n)
+	for len(segs) < n {
+		from := point{x: rng.Intn(size), y: rng.Intn(size)}
+		dir := dirs[rng.Intn(len(dirs))]
+		steps := rng.Intn(size)
+		to := point{x: from.x + dir.x*steps, y: from.y + dir.y*steps}
+		if to.x < 0 || to.x >= size || to.y < 0 || to.y >= size {
+			continue
+		}
+		if rng.Intn(2) == 0 {
+			from, to = to, from
+		}
+		segs = append(segs, segment{from: from, to: to})
+	}
+
+	return segs
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -29,6 +30,12 @@ func (p *point) travel(slope *slope) {
 	p.y += slope.y
 }
 
+// segment is a line of vents, both ends included
+type segment struct {
+	from point
+	to   point
+}
+
 const input = "day5/input.txt"
 
 // keep a track of all points we have seen. for each point we travel to the
@@ -36,119 +43,207 @@ const input = "day5/input.txt"
 // if we interact with any that have already been seen, that counts as an
 // overlap
 func main() {
-	if err := part1(); err != nil {
-		log.Fatalf("unable to complete part1: %v", err)
+	path := flag.String("input", input, "path to the puzzle input")
+	engine := flag.String("engine", "walk", "how overlaps are counted: walk or sweep")
+	raster := flag.String("raster", "unit", "how the walker steps along a segment: unit, lattice or bresenham")
+	render := flag.String("render", "", "draw the vents as ascii or png instead of solving")
+	part := flag.Int("part", 2, "which part's segments to draw")
+	crop := flag.String("crop", "", "only draw x0,y0,x1,y1, both corners included")
+	out := flag.String("out", "-", "where to write the drawing, - for stdout")
+	backend := flag.String("backend", "auto", "where the walker counts points: map, dense or auto")
+	at := flag.String("at", "", "list the segments covering x,y instead of solving")
+	rect := flag.String("rect", "", "count the overlaps inside x0,y0,x1,y1 instead of solving")
+	intersects := flag.Int("intersects", 0, "list the segments crossing this segment instead of solving")
+	min := flag.Int("min", 2, "a point is an overlap when at least this many lines cover it")
+	categories := flag.String("categories", "", "count overlaps of these kinds of lines instead of the parts: horizontal, vertical, diagonal")
+	split := flag.Bool("breakdown", false, "split the overlaps up by the kinds of lines covering them")
+	flag.Parse()
+
+	if *min < 1 {
+		log.Fatalf("an overlap needs at least one line")
 	}
 
-	if err := part2(); err != nil {
-		log.Fatalf("unable to complete part2: %v", err)
+	segs, err := getSegments(*path)
+	if err != nil {
+		log.Fatalf("unable to get segments: %v", err)
 	}
-}
 
-func part1() error {
-	s, err := inputScanner(input)
+	if *render != "" {
+		if *part == 1 {
+			segs = filterSegments(segs, horizontals|verticals)
+		}
+		if err := renderFile(*out, *render, *crop, segs, *raster); err != nil {
+			log.Fatalf("unable to render: %v", err)
+		}
+		return
+	}
+
+	if *at != "" || *rect != "" || *intersects > 0 {
+		if err := query(os.Stdout, segs, *at, *rect, *intersects, *min); err != nil {
+			log.Fatalf("unable to query: %v", err)
+		}
+		return
+	}
+
+	count, err := getEngine(*engine, *raster, *backend)
 	if err != nil {
-		log.Fatalf("unable to get input scanner: %v", err)
+		log.Fatalf("unable to get engine: %v", err)
 	}
 
-	// track the vertices we've already seen. if we encounter one already seen
-	// that counts as an overlap
-	seen := make(map[point]int)
-	for s.Scan() {
-		l := s.Text()
-		from, to, err := getPoints(strings.TrimSpace(l))
+	if *categories != "" {
+		cats, err := getCategories(*categories)
 		if err != nil {
-			log.Fatalf("unable to get points from line: %v", err)
+			log.Fatalf("unable to get categories: %v", err)
 		}
+		segs = filterSegments(segs, cats)
 
-		// part 1 rule
-		if from.x != to.x && from.y != to.y {
-			continue
+		n, err := count(segs, *min)
+		if err != nil {
+			log.Fatalf("unable to count overlaps: %v", err)
+		}
+		fmt.Printf("answer: %d\n", n)
+	} else {
+		if err := part1(segs, count, *min); err != nil {
+			log.Fatalf("unable to complete part1: %v", err)
 		}
 
-		// form vertices to go from->to
-		// add to seen map if we havent seen em
-		lineSlope := getUnitSlope(from, to)
-		to.travel(lineSlope)
-		for *from != *to {
-			_, ok := seen[*from]
-			//fmt.Println(from.String())
-			if ok {
-				seen[*from]++
-			} else {
-				seen[*from] = 1
-			}
-			from.travel(lineSlope)
+		if err := part2(segs, count, *min); err != nil {
+			log.Fatalf("unable to complete part2: %v", err)
 		}
 	}
 
-	if err := s.Err(); err != nil {
-		log.Fatalf("encountered error while scanning: %v", err)
+	if *split {
+		r, err := getRasterizer(*raster)
+		if err != nil {
+			log.Fatalf("unable to get rasterizer: %v", err)
+		}
+		counts, err := breakdown(segs, r, *min)
+		if err != nil {
+			log.Fatalf("unable to break down overlaps: %v", err)
+		}
+		if err := writeBreakdown(os.Stdout, counts); err != nil {
+			log.Fatalf("unable to write breakdown: %v", err)
+		}
 	}
+}
 
-	var twoOrMore int
-	for _, v := range seen {
-		if v > 1 {
-			twoOrMore++
-		}
+// part 1 only counts horizontal and vertical lines
+func part1(segs []segment, count engine, min int) error {
+	n, err := count(filterSegments(segs, horizontals|verticals), min)
+	if err != nil {
+		return fmt.Errorf("unable to count overlaps: %w", err)
 	}
 
-	fmt.Printf("answer: %d\n", twoOrMore)
+	fmt.Printf("answer: %d\n", n)
 
 	return nil
 }
 
-func part2() error {
-	s, err := inputScanner(input)
+func part2(segs []segment, count engine, min int) error {
+	n, err := count(segs, min)
 	if err != nil {
-		log.Fatalf("unable to get input scanner: %v", err)
+		return fmt.Errorf("unable to count overlaps: %w", err)
 	}
 
-	// track the vertices we've already seen. if we encounter one already seen
-	// that counts as an overlap
-	seen := make(map[point]int)
-	for s.Scan() {
-		l := s.Text()
-		from, to, err := getPoints(strings.TrimSpace(l))
+	fmt.Printf("answer: %d\n", n)
+
+	return nil
+}
+
+// engine counts the points covered by at least min segments
+type engine func(segs []segment, min int) (int, error)
+
+// the raster and backend only matter to the walker, the sweep engine works
+// the lines out itself and only supports horizontal, vertical and diagonal
+// segments, which every raster agrees on
+func getEngine(name string, raster string, backend string) (engine, error) {
+	switch name {
+	case "walk":
+		r, err := getRasterizer(raster)
 		if err != nil {
-			log.Fatalf("unable to get points from line: %v", err)
+			return nil, err
 		}
+		return func(segs []segment, min int) (int, error) {
+			return walkOverlaps(segs, r, backend, min)
+		}, nil
+	case "sweep":
+		return sweepOverlaps, nil
+	default:
+		return nil, fmt.Errorf("unsupported engine: %s", name)
+	}
+}
+
+func walkOverlaps(segs []segment, r rasterizer, backend string, min int) (int, error) {
+	c, err := newCounter(backend, segs)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := walkInto(c, segs, r); err != nil {
+		return 0, err
+	}
+
+	return c.overlaps(min), nil
+}
+
+// walk travels every segment point by point and tracks the # of segments
+// that cover each point
+func walk(segs []segment, r rasterizer) (map[point]int, error) {
+	// track the vertices we've already seen. if we encounter one already seen
+	// that counts as an overlap
+	seen := make(mapCounter)
+	if err := walkInto(seen, segs, r); err != nil {
+		return nil, err
+	}
+
+	return seen, nil
+}
 
-		// form vertices to go from->to
-		// add to seen map if we havent seen em
-		lineSlope := getUnitSlope(from, to)
-		to.travel(lineSlope)
-		for *from != *to {
-			_, ok := seen[*from]
-			//fmt.Println(from.String())
-			if ok {
-				seen[*from]++
-			} else {
-				seen[*from] = 1
-			}
-			from.travel(lineSlope)
+func walkInto(c counter, segs []segment, r rasterizer) error {
+	for i := range segs {
+		if err := r(segs[i], c.inc); err != nil {
+			return err
 		}
 	}
 
-	if err := s.Err(); err != nil {
-		log.Fatalf("encountered error while scanning: %v", err)
+	return nil
+}
+
+func getSegments(input string) ([]segment, error) {
+	s, err := inputScanner(input)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get input scanner: %w", err)
 	}
 
-	var twoOrMore int
-	for _, v := range seen {
-		if v > 1 {
-			twoOrMore++
+	var segs []segment
+	for s.Scan() {
+		l := strings.TrimSpace(s.Text())
+		if l == "" {
+			continue
 		}
+		from, to, err := getPoints(l)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get points from line: %w", err)
+		}
+		segs = append(segs, segment{from: *from, to: *to})
 	}
 
-	fmt.Printf("answer: %d\n", twoOrMore)
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("encountered error while scanning: %w", err)
+	}
 
-	return nil
+	return segs, nil
 }
 
-func getUnitSlope(from *point, to *point) *slope {
+func getUnitSlope(from *point, to *point) (*slope, error) {
 	if from == nil || to == nil {
-		return nil
+		return nil, nil
+	}
+
+	// stepping in 1s only ever reaches the end going straight or at 45
+	// degrees, anything else would step past it forever
+	if from.x != to.x && from.y != to.y && absSub(to.x, from.x) != absSub(to.y, from.y) {
+		return nil, fmt.Errorf("%d,%d -> %d,%d isn't horizontal, vertical or diagonal, try the lattice or bresenham raster", from.x, from.y, to.x, to.y)
 	}
 
 	// divide by itself because we only want to move in 1s
@@ -164,7 +259,7 @@ func getUnitSlope(from *point, to *point) *slope {
 		// divide by itself because we only want to move in 1s
 		x: x,
 		y: y,
-	}
+	}, nil
 }
 
 func getPoints(line string) (*point, *point, error) {
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// segments are bucketed into square cells this wide
+const indexCellSize = 32
+
+// segmentIndex answers questions about the segments without walking all of
+// them. Every segment is put in the buckets of the cells of a uniform grid
+// that it passes through, so a query only looks at the segments near it. The
+// points of a segment are its exact lattice points, the same ones the unit
+// and lattice rasters walk.
+type segmentIndex struct {
+	segs    []segment
+	buckets map[point][]int
+}
+
+func newSegmentIndex(segs []segment) *segmentIndex {
+	x := &segmentIndex{
+		segs:    segs,
+		buckets: make(map[point][]int),
+	}
+	for i, s := range segs {
+		var last point
+		first := true
+		_ = latticeRaster(s, func(p point) {
+			// consecutive points mostly share a cell
+			if c := cellOf(p); first || c != last {
+				b := x.buckets[c]
+				if len(b) == 0 || b[len(b)-1] != i {
+					x.buckets[c] = append(b, i)
+				}
+				last, first = c, false
+			}
+		})
+	}
+
+	return x
+}
+
+// covering returns the segments that pass through the point
+func (x *segmentIndex) covering(p point) []int {
+	var ids []int
+	for _, i := range x.buckets[cellOf(p)] {
+		if covers(x.segs[i], p) {
+			ids = append(ids, i)
+		}
+	}
+
+	return ids
+}
+
+// overlapsIn counts the points inside the window, edges included, that are
+// covered by at least min segments
+func (x *segmentIndex) overlapsIn(w window, min int) int {
+	seen := make(map[point]int)
+	for _, i := range x.near(w) {
+		walkClipped(x.segs[i], w, func(p point) {
+			seen[p]++
+		})
+	}
+
+	var count int
+	for _, v := range seen {
+		if v >= min {
+			count++
+		}
+	}
+
+	return count
+}
+
+// intersecting returns the other segments that share at least one point with
+// the ith segment
+func (x *segmentIndex) intersecting(i int) []int {
+	s := x.segs[i]
+	var ids []int
+	for _, j := range x.near(segmentBounds(s)) {
+		if j == i {
+			continue
+		}
+
+		// only the part of s inside the other segment's box can touch it
+		found := false
+		other := x.segs[j]
+		walkClipped(s, segmentBounds(other), func(p point) {
+			found = found || covers(other, p)
+		})
+		if found {
+			ids = append(ids, j)
+		}
+	}
+
+	return ids
+}
+
+// near returns every segment bucketed in a cell touching the window, in order.
+// a window with more cells than there are buckets goes through the buckets
+// instead of the cells
+func (x *segmentIndex) near(w window) []int {
+	lo, hi := cellOf(w.min), cellOf(w.max)
+	found := make(map[int]struct{})
+	cols, rows := hi.x-lo.x+1, hi.y-lo.y+1
+	if n := len(x.buckets); cols > n || rows > n || cols > n/rows {
+		for c, b := range x.buckets {
+			if c.x < lo.x || c.x > hi.x || c.y < lo.y || c.y > hi.y {
+				continue
+			}
+			for _, i := range b {
+				found[i] = struct{}{}
+			}
+		}
+	} else {
+		for cy := lo.y; cy <= hi.y; cy++ {
+			for cx := lo.x; cx <= hi.x; cx++ {
+				for _, i := range x.buckets[point{x: cx, y: cy}] {
+					found[i] = struct{}{}
+				}
+			}
+		}
+	}
+
+	ids := make([]int, 0, len(found))
+	for i := range found {
+		ids = append(ids, i)
+	}
+	sort.Ints(ids)
+
+	return ids
+}
+
+func cellOf(p point) point {
+	return point{x: floorDiv(p.x, indexCellSize), y: floorDiv(p.y, indexCellSize)}
+}
+
+// covers checks the point is in line with the segment, between its ends and
+// a whole # of lattice steps from the start
+func covers(s segment, p point) bool {
+	dx, dy := s.to.x-s.from.x, s.to.y-s.from.y
+	px, py := p.x-s.from.x, p.y-s.from.y
+	if dx*py != dy*px {
+		return false
+	}
+
+	b := segmentBounds(s)
+	if p.x < b.min.x || p.x > b.max.x || p.y < b.min.y || p.y > b.max.y {
+		return false
+	}
+
+	if dx == 0 && dy == 0 {
+		return true
+	}
+
+	n := gcd(absSub(dx, 0), absSub(dy, 0))
+	if dx != 0 {
+		return px%(dx/n) == 0
+	}
+
+	return py%(dy/n) == 0
+}
+
+// walkClipped visits the lattice points of the segment that are inside the
+// window without stepping through the ones outside of it
+func walkClipped(s segment, w window, visit func(p point)) {
+	if s.from == s.to {
+		if inWindow(s.from, w) {
+			visit(s.from)
+		}
+		return
+	}
+
+	dx, dy := s.to.x-s.from.x, s.to.y-s.from.y
+	n := gcd(absSub(dx, 0), absSub(dy, 0))
+	sx, sy := dx/n, dy/n
+
+	// the points are from + t*step for t in [0, n], narrow t down to the
+	// steps that land inside the window on both axes
+	lo, hi := 0, n
+	lo, hi = clipSteps(lo, hi, s.from.x, sx, w.min.x, w.max.x)
+	lo, hi = clipSteps(lo, hi, s.from.y, sy, w.min.y, w.max.y)
+	for t := lo; t <= hi; t++ {
+		visit(point{x: s.from.x + t*sx, y: s.from.y + t*sy})
+	}
+}
+
+// clipSteps keeps the t in [lo, hi] for which start + t*step is in [min, max]
+func clipSteps(lo, hi, start, step, min, max int) (int, int) {
+	switch {
+	case step == 0:
+		if start < min || start > max {
+			return 1, 0
+		}
+		return lo, hi
+	case step > 0:
+		lo = maxInt(lo, ceilDiv(min-start, step))
+		hi = minInt(hi, floorDiv(max-start, step))
+	default:
+		lo = maxInt(lo, ceilDiv(max-start, step))
+		hi = minInt(hi, floorDiv(min-start, step))
+	}
+
+	return lo, hi
+}
+
+func segmentBounds(s segment) window {
+	return bounds([]segment{s})
+}
+
+func inWindow(p point, w window) bool {
+	return p.x >= w.min.x && p.x <= w.max.x && p.y >= w.min.y && p.y <= w.max.y
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+
+	return q
+}
+
+func ceilDiv(a, b int) int {
+	return -floorDiv(-a, b)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+// query answers one of the questions from the flags. segments are 1 based,
+// the same as the line they're on in the input
+func query(w io.Writer, segs []segment, at, rect string, intersects, min int) error {
+	x := newSegmentIndex(segs)
+	switch {
+	case at != "":
+		p, err := getPoint(strings.TrimSpace(at))
+		if err != nil {
+			return fmt.Errorf("unable to get point: %w", err)
+		}
+		return writeSegments(w, segs, x.covering(p))
+	case rect != "":
+		win, err := getWindow(rect, nil)
+		if err != nil {
+			return fmt.Errorf("unable to get rectangle: %w", err)
+		}
+		fmt.Fprintf(w, "overlaps: %d\n", x.overlapsIn(win, min))
+		return nil
+	case intersects > 0:
+		if intersects > len(segs) {
+			return fmt.Errorf("there are only %d segments", len(segs))
+		}
+		return writeSegments(w, segs, x.intersecting(intersects-1))
+	default:
+		return fmt.Errorf("nothing to query")
+	}
+}
+
+func writeSegments(w io.Writer, segs []segment, ids []int) error {
+	for _, i := range ids {
+		s := segs[i]
+		_, err := fmt.Fprintf(w, "%d: %d,%d -> %d,%d\n", i+1, s.from.x, s.from.y, s.to.x, s.to.y)
+		if err != nil {
+			return fmt.Errorf("unable to write segment: %w", err)
+		}
+	}
+
+	return nil
+}
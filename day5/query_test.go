@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// TestIndex makes sure the segment index agrees with the walker on the whole
+// area, point by point and on which segments cross, for the input and random
+// inputs
+func TestIndex(t *testing.T) {
+	segs, err := getSegments("input.txt")
+	if err != nil {
+		t.Fatalf("unable to get segments: %v", err)
+	}
+	if err := compareIndex(segs); err != nil {
+		t.Errorf("input: %v", err)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < randomInputs; i++ {
+		if err := compareIndex(randomSegments(rng, 1+rng.Intn(50), 20)); err != nil {
+			t.Fatalf("random input %d: %v", i, err)
+		}
+	}
+}
+
+func compareIndex(segs []segment) error {
+	x := newSegmentIndex(segs)
+	for min := 1; min <= maxCheckedMin; min++ {
+		want, err := walkOverlaps(segs, unitRaster, "map", min)
+		if err != nil {
+			return err
+		}
+		if got := x.overlapsIn(bounds(segs), min); got != want {
+			return fmt.Errorf("index counted %d points covered %d+ times, walk counted %d in %v", got, min, want, segs)
+		}
+	}
+
+	seen, err := walk(segs, unitRaster)
+	if err != nil {
+		return err
+	}
+	for p, v := range seen {
+		if got := len(x.covering(p)); got != v {
+			return fmt.Errorf("index has %d segments covering %v, walk has %d in %v", got, p, v, segs)
+		}
+	}
+
+	// two segments intersect when any point covers both of them
+	crossed := make([]map[int]bool, len(segs))
+	for i := range crossed {
+		crossed[i] = make(map[int]bool)
+	}
+	for p := range seen {
+		ids := x.covering(p)
+		for _, i := range ids {
+			for _, j := range ids {
+				if i != j {
+					crossed[i][j] = true
+				}
+			}
+		}
+	}
+	for i := range segs {
+		if got := x.intersecting(i); len(got) != len(crossed[i]) {
+			return fmt.Errorf("index has %d segments crossing %v, walk has %d in %v", len(got), segs[i], len(crossed[i]), segs)
+		}
+	}
+
+	return nil
+}
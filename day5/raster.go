@@ -0,0 +1,105 @@
+package main
+
+import "fmt"
+
+// rasterizer visits the points covered by a segment, ends included
+type rasterizer func(s segment, visit func(p point)) error
+
+func getRasterizer(name string) (rasterizer, error) {
+	switch name {
+	case "unit":
+		return unitRaster, nil
+	case "lattice":
+		return latticeRaster, nil
+	case "bresenham":
+		return bresenhamRaster, nil
+	default:
+		return nil, fmt.Errorf("unsupported raster: %s", name)
+	}
+}
+
+// unitRaster is the puzzle's way of stepping, 1 at a time in x and/or y
+func unitRaster(s segment, visit func(p point)) error {
+	from, to := s.from, s.to
+	// a single point has no slope to travel along
+	if from == to {
+		visit(from)
+		return nil
+	}
+
+	// form vertices to go from->to
+	// add to seen map if we havent seen em
+	lineSlope, err := getUnitSlope(&from, &to)
+	if err != nil {
+		return err
+	}
+	to.travel(lineSlope)
+	for from != to {
+		visit(from)
+		from.travel(lineSlope)
+	}
+
+	return nil
+}
+
+// latticeRaster only visits the points the segment passes through exactly.
+// dividing the run and rise by their gcd gives the smallest step that lands
+// on whole coordinates, e.g. 0,0 -> 4,2 steps by 2,1
+func latticeRaster(s segment, visit func(p point)) error {
+	from, to := s.from, s.to
+	if from == to {
+		visit(from)
+		return nil
+	}
+
+	dx, dy := to.x-from.x, to.y-from.y
+	d := gcd(absSub(dx, 0), absSub(dy, 0))
+	step := &slope{x: dx / d, y: dy / d}
+	to.travel(step)
+	for from != to {
+		visit(from)
+		from.travel(step)
+	}
+
+	return nil
+}
+
+// bresenhamRaster visits the closest point to the segment for every step
+// along its longer axis, so the segment is drawn without gaps
+func bresenhamRaster(s segment, visit func(p point)) error {
+	p := s.from
+	dx, dy := absSub(s.to.x, s.from.x), -absSub(s.to.y, s.from.y)
+	sx, sy := 1, 1
+	if s.to.x < s.from.x {
+		sx = -1
+	}
+	if s.to.y < s.from.y {
+		sy = -1
+	}
+
+	e := dx + dy
+	for {
+		visit(p)
+		if p == s.to {
+			return nil
+		}
+
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			p.x += sx
+		}
+		if e2 <= dx {
+			e += dx
+			p.y += sy
+		}
+	}
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+
+	return a
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRasters(t *testing.T) {
+	tests := []struct {
+		raster string
+		seg    segment
+		want   []point
+		// err is set when the raster can't draw the segment
+		err bool
+	}{
+		{raster: "unit", seg: segment{from: point{0, 0}, to: point{4, 2}}, err: true},
+		{raster: "unit", seg: segment{from: point{3, 3}, to: point{0, 0}}, want: []point{{3, 3}, {2, 2}, {1, 1}, {0, 0}}},
+		{raster: "unit", seg: segment{from: point{2, 5}, to: point{2, 5}}, want: []point{{2, 5}}},
+		{raster: "lattice", seg: segment{from: point{0, 0}, to: point{4, 2}}, want: []point{{0, 0}, {2, 1}, {4, 2}}},
+		{raster: "lattice", seg: segment{from: point{6, 0}, to: point{0, 4}}, want: []point{{6, 0}, {3, 2}, {0, 4}}},
+		{raster: "lattice", seg: segment{from: point{0, 0}, to: point{0, 2}}, want: []point{{0, 0}, {0, 1}, {0, 2}}},
+		{raster: "bresenham", seg: segment{from: point{0, 0}, to: point{4, 2}}, want: []point{{0, 0}, {1, 1}, {2, 1}, {3, 2}, {4, 2}}},
+		{raster: "bresenham", seg: segment{from: point{0, 0}, to: point{2, 2}}, want: []point{{0, 0}, {1, 1}, {2, 2}}},
+	}
+
+	for _, tt := range tests {
+		r, err := getRasterizer(tt.raster)
+		if err != nil {
+			t.Fatalf("unable to get raster: %v", err)
+		}
+
+		var got []point
+		err = r(tt.seg, func(p point) {
+			got = append(got, p)
+		})
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s %v: expected an error, got %v", tt.raster, tt.seg, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %v: unable to rasterize: %v", tt.raster, tt.seg, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s %v: got %v, want %v", tt.raster, tt.seg, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ascii drawings get unreadable well before these
+	maxASCIIWidth  = 500
+	maxASCIIHeight = 2000
+	// an RGBA image takes 4 bytes a pixel, so this caps a png at ~100MB
+	maxPNGArea = 25_000_000
+)
+
+// window is the part of the diagram to draw, both corners included
+type window struct {
+	min point
+	max point
+}
+
+func (w window) width() int {
+	return w.max.x - w.min.x + 1
+}
+
+func (w window) height() int {
+	return w.max.y - w.min.y + 1
+}
+
+// getWindow parses a crop of x0,y0,x1,y1. Without one the window goes from
+// 0,0 like the diagrams in the puzzle to the furthest point seen
+func getWindow(crop string, seen map[point]int) (window, error) {
+	if crop == "" {
+		var w window
+		for p := range seen {
+			if p.x > w.max.x {
+				w.max.x = p.x
+			}
+			if p.y > w.max.y {
+				w.max.y = p.y
+			}
+			if p.x < w.min.x {
+				w.min.x = p.x
+			}
+			if p.y < w.min.y {
+				w.min.y = p.y
+			}
+		}
+		return w, nil
+	}
+
+	parts := strings.Split(crop, ",")
+	if len(parts) != 4 {
+		return window{}, fmt.Errorf("expected x0,y0,x1,y1, got %s", crop)
+	}
+	var coords [4]int
+	for i := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(parts[i]))
+		if err != nil {
+			return window{}, fmt.Errorf("unable to get crop coord: %w", err)
+		}
+		coords[i] = n
+	}
+
+	w := window{
+		min: point{x: coords[0], y: coords[1]},
+		max: point{x: coords[2], y: coords[3]},
+	}
+	if w.max.x < w.min.x || w.max.y < w.min.y {
+		return window{}, fmt.Errorf("crop corners are the wrong way round: %s", crop)
+	}
+
+	return w, nil
+}
+
+func renderFile(path string, format string, crop string, segs []segment, raster string) error {
+	r, err := getRasterizer(raster)
+	if err != nil {
+		return err
+	}
+
+	seen, err := walk(segs, r)
+	if err != nil {
+		return fmt.Errorf("unable to walk segments: %w", err)
+	}
+
+	win, err := getWindow(crop, seen)
+	if err != nil {
+		return fmt.Errorf("unable to get window: %w", err)
+	}
+
+	var render func(w io.Writer, seen map[point]int, win window) error
+	switch format {
+	case "ascii":
+		render = renderASCII
+	case "png":
+		render = renderPNG
+	default:
+		return fmt.Errorf("unsupported render format: %s", format)
+	}
+
+	if path == "-" {
+		return render(os.Stdout, seen, win)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("unable to create file: %w", err)
+	}
+
+	if err := render(f, seen, win); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// renderASCII draws the diagram the way the puzzle does, a . where there are
+// no vents and otherwise the # of lines covering the point. anything past 9
+// is drawn as a #
+func renderASCII(w io.Writer, seen map[point]int, win window) error {
+	if win.width() > maxASCIIWidth {
+		return fmt.Errorf("%d columns is too wide to draw, try -crop or png", win.width())
+	}
+	if win.height() > maxASCIIHeight {
+		return fmt.Errorf("%d rows is too tall to draw, try -crop or png", win.height())
+	}
+
+	bw := bufio.NewWriter(w)
+	for y := win.min.y; y <= win.max.y; y++ {
+		for x := win.min.x; x <= win.max.x; x++ {
+			switch v := seen[point{x: x, y: y}]; {
+			case v == 0:
+				bw.WriteByte('.')
+			case v > 9:
+				bw.WriteByte('#')
+			default:
+				bw.WriteByte(byte('0' + v))
+			}
+		}
+		bw.WriteByte('\n')
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("unable to write diagram: %w", err)
+	}
+
+	return nil
+}
+
+// renderPNG draws a pixel per point, black where there are no vents and
+// brighter the more lines cover a point
+func renderPNG(w io.Writer, seen map[point]int, win window) error {
+	// divide instead of multiplying so a huge window can't overflow
+	if win.width() > maxPNGArea/win.height() {
+		return fmt.Errorf("%dx%d is too big to draw, try -crop", win.width(), win.height())
+	}
+
+	var most int
+	for p, v := range seen {
+		if v > most && p.x >= win.min.x && p.x <= win.max.x && p.y >= win.min.y && p.y <= win.max.y {
+			most = v
+		}
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, win.width(), win.height()))
+	for y := 0; y < win.height(); y++ {
+		for x := 0; x < win.width(); x++ {
+			v := seen[point{x: win.min.x + x, y: win.min.y + y}]
+			img.Set(x, y, heat(v, most))
+		}
+	}
+
+	if err := png.Encode(w, img); err != nil {
+		return fmt.Errorf("unable to encode png: %w", err)
+	}
+
+	return nil
+}
+
+// heat goes from dark red for a single line through orange to yellow for the
+// most overlapped points
+func heat(v, most int) color.Color {
+	if v == 0 {
+		return color.Black
+	}
+
+	// a single line is the bottom of the scale
+	t := 1.0
+	if most > 1 {
+		t = float64(v-1) / float64(most-1)
+	}
+
+	return color.RGBA{
+		R: uint8(128 + 127*t),
+		G: uint8(220 * t),
+		B: uint8(40 * t),
+		A: 255,
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"io"
+	"testing"
+)
+
+func TestRenderLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		render func(w io.Writer, seen map[point]int, win window) error
+		win    window
+		err    bool
+	}{
+		{name: "ascii", render: renderASCII, win: window{max: point{x: maxASCIIWidth - 1, y: maxASCIIHeight - 1}}},
+		{name: "ascii too wide", render: renderASCII, win: window{max: point{x: maxASCIIWidth, y: 0}}, err: true},
+		{name: "ascii too tall", render: renderASCII, win: window{max: point{x: 0, y: maxASCIIHeight}}, err: true},
+		{name: "png", render: renderPNG, win: window{max: point{x: 99, y: 99}}},
+		{name: "png too big", render: renderPNG, win: window{max: point{x: maxPNGArea / 1000, y: 999}}, err: true},
+		{name: "png far apart corners", render: renderPNG, win: window{min: point{x: -1 << 20, y: -1 << 20}, max: point{x: 1 << 20, y: 1 << 20}}, err: true},
+	}
+
+	seen := map[point]int{{x: 0, y: 0}: 1}
+	for _, tt := range tests {
+		err := tt.render(io.Discard, seen, tt.win)
+		if tt.err && err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+		if !tt.err && err != nil {
+			t.Errorf("%s: unable to render: %v", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// a family holds segments that run the same direction. every line of a family
+// is a*x + b*y = key and positions along the line are measured in x, or in y
+// for vertical lines
+type family int
+
+const (
+	horizontal family = iota
+	vertical
+	diagonal     // x - y = key
+	antiDiagonal // x + y = key
+	families
+)
+
+var coefficients = [families][2]int{
+	horizontal:   {0, 1},
+	vertical:     {1, 0},
+	diagonal:     {1, -1},
+	antiDiagonal: {1, 1},
+}
+
+func (f family) key(p point) int {
+	return coefficients[f][0]*p.x + coefficients[f][1]*p.y
+}
+
+func (f family) pos(p point) int {
+	if f == vertical {
+		return p.y
+	}
+
+	return p.x
+}
+
+// getFamily only knows about the directions the puzzle uses, single points
+// are treated as a horizontal line
+func getFamily(s segment) (family, bool) {
+	dx, dy := s.to.x-s.from.x, s.to.y-s.from.y
+	switch {
+	case dy == 0:
+		return horizontal, true
+	case dx == 0:
+		return vertical, true
+	case dx == dy:
+		return diagonal, true
+	case dx == -dy:
+		return antiDiagonal, true
+	default:
+		return 0, false
+	}
+}
+
+// interval is a closed range of positions along a line
+type interval struct {
+	lo int
+	hi int
+}
+
+func (i interval) len() int {
+	return i.hi - i.lo + 1
+}
+
+// run is a stretch of a line covered by the same # of segments
+type run struct {
+	interval
+	depth int
+}
+
+// coverage is what a sweep along a single line leaves behind, the covered
+// runs in order
+type coverage []run
+
+func (c coverage) depth(pos int) int {
+	i := sort.Search(len(c), func(i int) bool { return c[i].hi >= pos })
+	if i < len(c) && c[i].lo <= pos {
+		return c[i].depth
+	}
+
+	return 0
+}
+
+// sweepOverlaps counts overlap points without visiting them. Segments are
+// grouped by family and line, a sweep over the ends of the segments on a line
+// gives how many segments cover each stretch of it. Lines of different
+// families only ever cross at single points, those are found by intersecting
+// the lines and are corrected for so no point is counted twice.
+func sweepOverlaps(segs []segment, min int) (int, error) {
+	var lines [families]map[int][]interval
+	for f := range lines {
+		lines[f] = make(map[int][]interval)
+	}
+	for _, s := range segs {
+		f, ok := getFamily(s)
+		if !ok {
+			return 0, fmt.Errorf("the sweep engine only supports horizontal, vertical and diagonal segments, got %d,%d -> %d,%d", s.from.x, s.from.y, s.to.x, s.to.y)
+		}
+		lo, hi := f.pos(s.from), f.pos(s.to)
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		k := f.key(s.from)
+		lines[f][k] = append(lines[f][k], interval{lo: lo, hi: hi})
+	}
+
+	var count int
+	var swept [families]map[int]coverage
+	for f := range lines {
+		swept[f] = make(map[int]coverage, len(lines[f]))
+		for k, ivs := range lines[f] {
+			c := sweep(ivs)
+			for _, r := range c {
+				if r.depth >= min {
+					count += r.len()
+				}
+			}
+			swept[f][k] = c
+		}
+	}
+
+	// points on lines of two or more families. a point can come up for more
+	// than one pair of families so they are gathered first
+	crossings := make(map[point]struct{})
+	for fa := family(0); fa < families; fa++ {
+		for fb := fa + 1; fb < families; fb++ {
+			for ka, ca := range swept[fa] {
+				for kb, cb := range swept[fb] {
+					p, ok := intersect(fa, ka, fb, kb)
+					if !ok {
+						continue
+					}
+					if ca.depth(fa.pos(p)) > 0 && cb.depth(fb.pos(p)) > 0 {
+						crossings[p] = struct{}{}
+					}
+				}
+			}
+		}
+	}
+
+	for p := range crossings {
+		// the # of families that already counted this point on their own
+		var total, counted int
+		for f := family(0); f < families; f++ {
+			d := swept[f][f.key(p)].depth(f.pos(p))
+			total += d
+			if d >= min {
+				counted++
+			}
+		}
+
+		// the point should be counted once if all the families together
+		// cover it enough
+		if total >= min {
+			count++
+		}
+		count -= counted
+	}
+
+	return count, nil
+}
+
+// sweep walks the ends of the intervals in order keeping a running # of
+// intervals covering the current position
+func sweep(ivs []interval) coverage {
+	type event struct {
+		pos   int
+		delta int
+	}
+	events := make([]event, 0, len(ivs)*2)
+	for _, iv := range ivs {
+		// an interval stops covering one past its end
+		events = append(events, event{pos: iv.lo, delta: 1}, event{pos: iv.hi + 1, delta: -1})
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].pos < events[j].pos
+	})
+
+	var c coverage
+	var depth int
+	for i := 0; i < len(events); {
+		pos := events[i].pos
+		if depth > 0 {
+			c[len(c)-1].hi = pos - 1
+		}
+		for ; i < len(events) && events[i].pos == pos; i++ {
+			depth += events[i].delta
+		}
+		if depth > 0 {
+			c = append(c, run{interval: interval{lo: pos}, depth: depth})
+		}
+	}
+
+	return c
+}
+
+// intersect solves the two line equations, lines that cross between lattice
+// points don't count
+func intersect(fa family, ka int, fb family, kb int) (point, bool) {
+	a1, b1 := coefficients[fa][0], coefficients[fa][1]
+	a2, b2 := coefficients[fb][0], coefficients[fb][1]
+	det := a1*b2 - a2*b1
+	if det == 0 {
+		return point{}, false
+	}
+
+	x := ka*b2 - kb*b1
+	y := a1*kb - a2*ka
+	if x%det != 0 || y%det != 0 {
+		return point{}, false
+	}
+
+	return point{x: x / det, y: y / det}, true
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// descendants remembers how many fish a single fish turns into. A fish at
+// timer t after d days is the same as a fish at 0 after d-t days, and a fish
+// at 0 splits into one at 6 and one at 8 the next day, so one series covers
+// every timer:
+//
+//	g(d) = 1                 for d <= 0
+//	g(d) = g(d-7) + g(d-9)   otherwise
+//
+// Queries for a day past the end of the series grow it, every other query is
+// a sum over the 9 timers.
+type descendants struct {
+	// counts[d] is g(d)
+	counts []*big.Int
+}
+
+func newDescendants() *descendants {
+	return &descendants{counts: []*big.Int{big.NewInt(1)}}
+}
+
+// of is the # of fish one fish at the timer turns into after the days
+func (c *descendants) of(timer, days int) *big.Int {
+	d := days - timer
+	if d <= 0 {
+		return c.counts[0]
+	}
+
+	for n := len(c.counts); n <= d; n++ {
+		c.counts = append(c.counts, new(big.Int).Add(c.at(n-7), c.at(n-9)))
+	}
+
+	return c.counts[d]
+}
+
+func (c *descendants) at(d int) *big.Int {
+	if d <= 0 {
+		return c.counts[0]
+	}
+
+	return c.counts[d]
+}
+
+// Population is the # of fish the initial timers turn into after the days
+func (c *descendants) Population(initial []int, days int) (*big.Int, error) {
+	counts := make([]int, 9)
+	for i, t := range initial {
+		if t < 0 || t >= len(counts) {
+			return nil, fmt.Errorf("fish %d: timer %d is outside of 0-%d", i+1, t, len(counts)-1)
+		}
+		counts[t]++
+	}
+
+	return c.histogram(counts, days), nil
+}
+
+// histogram is Population for fish already counted by timer
+func (c *descendants) histogram(counts []int, days int) *big.Int {
+	sum := new(big.Int)
+	var prod big.Int
+	for t, n := range counts {
+		if n == 0 {
+			continue
+		}
+		prod.Mul(c.of(t, days), big.NewInt(int64(n)))
+		sum.Add(sum, &prod)
+	}
+
+	return sum
+}
+
+// batch answers a query per line and writes the answers in the same order.
+// a query is a # of days, optionally followed by the fish to start with,
+// the puzzle input is used otherwise:
+//
+//	# the two parts
+//	80
+//	256
+//	18: 3,4,3,1,2
+func batch(w io.Writer, r io.Reader, input []int) error {
+	c := newDescendants()
+	s := bufio.NewScanner(r)
+	var line int
+	for s.Scan() {
+		line++
+		l := strings.TrimSpace(s.Text())
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(l, ":", 2)
+		days, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return fmt.Errorf("line %d: unable to convert days: %w", line, err)
+		}
+		if days < 0 {
+			return fmt.Errorf("line %d: days can't be negative", line)
+		}
+
+		counts := input
+		if len(parts) == 2 {
+			if counts, err = parseFish(parts[1]); err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+
+		if _, err := fmt.Fprintf(w, "%s\n", c.histogram(counts, days)); err != nil {
+			return fmt.Errorf("unable to write answer: %w", err)
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return fmt.Errorf("encountered error while scanning: %w", err)
+	}
+
+	return nil
+}
+
+func batchFile(path string, input []int) error {
+	if path == "-" {
+		return batch(os.Stdout, os.Stdin, input)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("unable to open file: %w", err)
+	}
+	defer f.Close()
+
+	return batch(os.Stdout, f, input)
+}
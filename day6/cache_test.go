@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPopulation(t *testing.T) {
+	example := []int{3, 4, 3, 1, 2}
+	tests := []struct {
+		days int
+		want string
+	}{
+		{days: 0, want: "5"},
+		{days: 18, want: "26"},
+		{days: 80, want: "5934"},
+		{days: 256, want: "26984457539"},
+	}
+
+	c := newDescendants()
+	for _, tt := range tests {
+		got, err := c.Population(example, tt.days)
+		if err != nil {
+			t.Fatalf("day %d: unable to get population: %v", tt.days, err)
+		}
+		if got.String() != tt.want {
+			t.Errorf("day %d: got %s fish, want %s", tt.days, got, tt.want)
+		}
+	}
+
+	for _, timer := range []int{-1, 9} {
+		if _, err := c.Population([]int{3, timer}, 18); err == nil {
+			t.Errorf("timer %d: expected an error", timer)
+		}
+	}
+}
+
+func TestBatch(t *testing.T) {
+	input, err := parseFish("3,4,3,1,2")
+	if err != nil {
+		t.Fatalf("unable to parse fish: %v", err)
+	}
+
+	queries := "# the two parts\n" +
+		"80\n" +
+		"256\n" +
+		"\n" +
+		"18: 3,4,3,1,2\n" +
+		"1: 0,0\n"
+	var out bytes.Buffer
+	if err := batch(&out, strings.NewReader(queries), input); err != nil {
+		t.Fatalf("unable to run batch: %v", err)
+	}
+	if got, want := out.String(), "5934\n26984457539\n26\n4\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	for _, bad := range []string{"x", "-1", "18: 3,9", "18: 3,,4"} {
+		if err := batch(&out, strings.NewReader(bad), input); err == nil {
+			t.Errorf("%s: expected an error", bad)
+		}
+	}
+}
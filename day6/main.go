@@ -1,9 +1,15 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
+	"math"
+	"math/big"
+	"os"
+	"runtime"
 	"strconv"
 	"strings"
 )
@@ -14,29 +20,227 @@ const input = "day6/input.txt"
 // represents the ith latern fish and the value of at each index represents
 // the total count in i.
 func main() {
-	inputB, err := ioutil.ReadFile(input)
+	path := flag.String("input", input, "path to the puzzle input, - for stdin")
+	days := flag.Int("days", 0, "also print the population after this many days")
+	model := flag.String("model", "matrix", "how the population is worked out: matrix, cache or loop, which only goes until the counts overflow")
+	config := flag.String("config", "", "simulate the species in this file instead of the puzzle's fish")
+	custom := lanternfish
+	flag.IntVar(&custom.cycle, "cycle", custom.cycle, "days between spawns")
+	flag.IntVar(&custom.juvenile, "juvenile", custom.juvenile, "extra days before a new fish's first spawn")
+	flag.IntVar(&custom.offspring, "offspring", custom.offspring, "new fish per spawn")
+	flag.IntVar(&custom.deathAge, "death", custom.deathAge, "days a fish lives, 0 for forever")
+	format := flag.String("series", "", "write the population of every reported day as csv or json instead of solving")
+	report := flag.String("report", "0-256", "days to put in the series, e.g. 0-10,80,256")
+	replicates := flag.Int("stochastic", 0, "run this many random replicates of the fish instead of solving")
+	interval := flag.String("interval", "7", "spawn interval distribution of the random fish, e.g. uniform:6-8, normal:7,1, poisson:7 or weights:6=1,7=3")
+	mortality := flag.Float64("mortality", 0, "chance a random fish dies each day")
+	percentiles := flag.String("percentiles", "5,50,95", "percentiles of the random populations to report")
+	workers := flag.Int("workers", runtime.NumCPU(), "# of goroutines running replicates")
+	seed := flag.Int64("seed", 1, "seed for anything random")
+	flag.Parse()
+
+	nums, err := getFish(*path)
 	if err != nil {
-		log.Fatalf("unable to read file")
+		log.Fatalf("unable to get fish: %v", err)
+	}
+
+	// day6 batch <queries> answers every query in the file, - for stdin
+	if flag.Arg(0) == "batch" {
+		if flag.NArg() != 2 {
+			log.Fatalf("usage: day6 batch <queries>")
+		}
+		if err := batchFile(flag.Arg(1), nums); err != nil {
+			log.Fatalf("unable to answer queries: %v", err)
+		}
+		return
+	}
+
+	if *replicates > 0 {
+		if *workers < 1 {
+			log.Fatalf("need at least one worker")
+		}
+		if err := runStochastic(nums, *interval, custom, *mortality, *report, *percentiles, *replicates, *workers, *seed); err != nil {
+			log.Fatalf("unable to run replicates: %v", err)
+		}
+		return
+	}
+
+	// any of the species flags switches over to the species simulation
+	var all []species
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "cycle", "juvenile", "offspring", "death":
+			custom.name = "custom"
+			all = []species{custom}
+		}
+	})
+	if *config != "" {
+		if all, err = getSpecies(*config); err != nil {
+			log.Fatalf("unable to get species: %v", err)
+		}
+	}
+	if *format != "" {
+		if len(all) == 0 {
+			all = []species{lanternfish}
+		}
+		if err := writeAllSeries(all, nums, *format, *report); err != nil {
+			log.Fatalf("unable to write series: %v", err)
+		}
+		return
+	}
+
+	if len(all) > 0 {
+		if err := simulateAll(all, nums, []int{80, 256, *days}); err != nil {
+			log.Fatalf("unable to simulate species: %v", err)
+		}
+		return
+	}
+
+	population, err := getModel(*model)
+	if err != nil {
+		log.Fatalf("unable to get model: %v", err)
+	}
+
+	answer := func(name string, days int) {
+		n, err := population(nums, days)
+		if err != nil {
+			log.Fatalf("unable to get population: %v", err)
+		}
+		fmt.Printf("%s: %s\n", name, n)
+	}
+
+	answer("part 1 answer", 80)
+	answer("part 2 answer", 256)
+	if *days > 0 {
+		answer(fmt.Sprintf("day %d", *days), *days)
+	}
+}
+
+// runStochastic takes the juvenile delay and offspring from the species flags,
+// the cycle comes from the interval distribution instead. random fish only
+// die from the daily mortality, not of old age
+func runStochastic(nums []int, interval string, s species, mortality float64, report, percentiles string, replicates, workers int, seed int64) error {
+	pmf, err := getDistribution(interval)
+	if err != nil {
+		return fmt.Errorf("unable to get interval distribution: %w", err)
+	}
+	switch {
+	case mortality < 0 || mortality > 1:
+		return fmt.Errorf("mortality is a chance between 0 and 1")
+	case s.juvenile < 0:
+		return fmt.Errorf("juvenile delay can't be negative")
+	case s.offspring < 0:
+		return fmt.Errorf("offspring can't be negative")
+	case s.deathAge != 0:
+		return fmt.Errorf("random fish don't die of old age, use -mortality instead of -death")
+	}
+
+	days, err := getDays(report)
+	if err != nil {
+		return err
+	}
+	ps, err := getPercentiles(percentiles)
+	if err != nil {
+		return err
+	}
+
+	st := stochastic{interval: pmf, juvenile: s.juvenile, offspring: s.offspring, mortality: mortality}
+	spreads, err := runReplicates(st, nums, days, replicates, workers, seed, ps)
+	if err != nil {
+		return err
+	}
+
+	return writeSpreads(os.Stdout, spreads, ps)
+}
+
+// getFish reads the list of timers and counts the fish on each one
+func getFish(path string) ([]int, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open file: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read input: %w", err)
+	}
+
+	return parseFish(string(b))
+}
+
+// parseFish turns the comma separated timers into a count per timer. spaces
+// and newlines around the timers are fine, gaps between commas aren't
+func parseFish(list string) ([]int, error) {
+	list = strings.TrimSpace(list)
+	if list == "" {
+		return nil, fmt.Errorf("no fish in the input")
 	}
 
-	numsStr := strings.Split(string(inputB), ",")
 	// 0 - 8
 	nums := make([]int, 9)
-	for i := range numsStr {
-		n, err := strconv.Atoi(numsStr[i])
+	for i, field := range strings.Split(list, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			return nil, fmt.Errorf("fish %d: empty timer", i+1)
+		}
+
+		n, err := strconv.Atoi(field)
 		if err != nil {
-			log.Fatalf("unable to convert to num: %v", err)
+			return nil, fmt.Errorf("fish %d: unable to convert to num: %w", i+1, err)
+		}
+		if n < 0 || n >= len(nums) {
+			return nil, fmt.Errorf("fish %d: timer %d is outside of 0-%d", i+1, n, len(nums)-1)
 		}
 		nums[n]++
 	}
 
-	// simulate through the days
-	const days = 256
+	return nums, nil
+}
+
+// model works out the total population after a # of days
+type model func(nums []int, days int) (*big.Int, error)
+
+func getModel(name string) (model, error) {
+	switch name {
+	case "loop":
+		return func(nums []int, days int) (*big.Int, error) {
+			counts, err := simulate(nums, days)
+			if err != nil {
+				return nil, err
+			}
+			return big.NewInt(int64(total(counts))), nil
+		}, nil
+	case "matrix":
+		return func(nums []int, days int) (*big.Int, error) {
+			return matrixPopulation(nums, days), nil
+		}, nil
+	case "cache":
+		c := newDescendants()
+		return func(nums []int, days int) (*big.Int, error) {
+			return c.histogram(nums, days), nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported model: %s", name)
+	}
+}
+
+// simulate through the days. the counts are ints so they run out a little
+// past 400 days, the days after that are refused rather than overflowing. see
+// matrixPopulation for more
+func simulate(start []int, days int) ([]int, error) {
+	nums := append([]int(nil), start...)
 	var new int
 	for i := 0; i < days; i++ {
-		if i == 80 {
-			fmt.Printf("part 1 answer: %d\n", total(nums))
+		// every fish at 0 adds one more fish
+		if total(nums) > math.MaxInt-nums[0] {
+			return nil, fmt.Errorf("the loop model overflows after day %d, use the matrix or cache model", i)
 		}
+
 		for j := range nums {
 			if nums[j] == 0 {
 				continue
@@ -62,7 +266,7 @@ func main() {
 		new = 0
 	}
 
-	fmt.Printf("part 2 answer: %d\n", total(nums))
+	return nums, nil
 }
 
 func total(nums []int) int {
@@ -0,0 +1,89 @@
+package main
+
+import "math/big"
+
+// a day is a linear map from one histogram to the next, so n days is the
+// same map applied n times. squaring the matrix gets there in O(log n)
+// matrix multiplications and big ints keep the counts from overflowing
+type matrix [9][9]*big.Int
+
+func newMatrix() matrix {
+	var m matrix
+	for i := range m {
+		for j := range m[i] {
+			m[i][j] = new(big.Int)
+		}
+	}
+
+	return m
+}
+
+func identity() matrix {
+	m := newMatrix()
+	for i := range m {
+		m[i][i].SetInt64(1)
+	}
+
+	return m
+}
+
+// transition moves every timer down by one, 0s go back to 6 and each of
+// them adds a new fish at 8. m[i][j] is how many fish at timer i tomorrow
+// come from one fish at timer j today
+func transition() matrix {
+	m := newMatrix()
+	for j := 1; j < 9; j++ {
+		m[j-1][j].SetInt64(1)
+	}
+	m[6][0].SetInt64(1)
+	m[8][0].SetInt64(1)
+
+	return m
+}
+
+func (a matrix) mul(b matrix) matrix {
+	m := newMatrix()
+	var prod big.Int
+	for i := range a {
+		for k := range a {
+			if a[i][k].Sign() == 0 {
+				continue
+			}
+			for j := range b {
+				prod.Mul(a[i][k], b[k][j])
+				m[i][j].Add(m[i][j], &prod)
+			}
+		}
+	}
+
+	return m
+}
+
+func (a matrix) pow(n int) matrix {
+	result := identity()
+	for n > 0 {
+		if n&1 == 1 {
+			result = result.mul(a)
+		}
+		a = a.mul(a)
+		n >>= 1
+	}
+
+	return result
+}
+
+func matrixPopulation(nums []int, days int) *big.Int {
+	m := transition().pow(days)
+
+	sum := new(big.Int)
+	var prod, count big.Int
+	for i := range m {
+		for j := range m[i] {
+			count.SetInt64(int64(nums[j]))
+			prod.Mul(m[i][j], &count)
+			sum.Add(sum, &prod)
+		}
+	}
+
+	return sum
+}
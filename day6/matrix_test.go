@@ -0,0 +1,48 @@
+package main
+
+import (
+	"io/ioutil"
+	"math/big"
+	"testing"
+)
+
+// TestModels compares the matrix, cache and species models against the day by
+// day loop for every day until the loop would overflow
+func TestModels(t *testing.T) {
+	data, err := ioutil.ReadFile("input.txt")
+	if err != nil {
+		t.Fatalf("unable to read input: %v", err)
+	}
+
+	for _, list := range []string{"3,4,3,1,2", string(data)} {
+		nums, err := parseFish(list)
+		if err != nil {
+			t.Fatalf("unable to parse fish: %v", err)
+		}
+
+		cache := newDescendants()
+		for d := 0; ; d++ {
+			counts, err := simulate(nums, d)
+			if err != nil {
+				// the loop should only stop once the fish don't fit in an int
+				if got := matrixPopulation(nums, d); got.IsInt64() {
+					t.Fatalf("day %d: loop stopped at %s fish: %v", d, got, err)
+				}
+				break
+			}
+			want := big.NewInt(int64(total(counts)))
+
+			if got := matrixPopulation(nums, d); got.Cmp(want) != 0 {
+				t.Fatalf("day %d: matrix has %s fish, loop has %s", d, got, want)
+			}
+			if got := cache.histogram(nums, d); got.Cmp(want) != 0 {
+				t.Fatalf("day %d: cache has %s fish, loop has %s", d, got, want)
+			}
+			// the general species model has to match with the puzzle's
+			// settings
+			if got := simulateSpecies(lanternfish, nums, d).total(); got.Cmp(want) != 0 {
+				t.Fatalf("day %d: species model has %s fish, loop has %s", d, got, want)
+			}
+		}
+	}
+}
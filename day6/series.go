@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// snapshot is the population of a species at the end of a day
+type snapshot struct {
+	Species string     `json:"species"`
+	Day     int        `json:"day"`
+	Timers  []*big.Int `json:"timers"`
+	Total   *big.Int   `json:"total"`
+}
+
+// growth sums up how fast a species grew between the first and last days
+// that were reported
+type growth struct {
+	species string
+	from    int
+	to      int
+	// rate is the average daily growth, 0.1 is 10% more fish every day
+	rate float64
+	// doubling is the # of days it takes the population to double at that
+	// rate, infinite when it isn't growing
+	doubling float64
+}
+
+// getDays parses the days to report, a comma separated list of days and
+// ranges like 0-10,80,256. they come back sorted without repeats
+func getDays(spec string) ([]int, error) {
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		bounds := strings.SplitN(part, "-", 2)
+		from, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert day: %w", err)
+		}
+		to := from
+		if len(bounds) == 2 {
+			if to, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, fmt.Errorf("unable to convert day: %w", err)
+			}
+		}
+		if from < 0 || to < from {
+			return nil, fmt.Errorf("invalid days: %s", part)
+		}
+
+		for d := from; d <= to; d++ {
+			seen[d] = true
+		}
+	}
+
+	if len(seen) == 0 {
+		return nil, fmt.Errorf("no days to report")
+	}
+
+	days := make([]int, 0, len(seen))
+	for d := range seen {
+		days = append(days, d)
+	}
+	sort.Ints(days)
+
+	return days, nil
+}
+
+// series steps through every day up to the last one reported, keeping the
+// histogram and total of the days asked for
+func series(s species, counts []int, days []int) []snapshot {
+	var snaps []snapshot
+	p := startPopulation(s, counts)
+	next := 0
+	for d := 0; next < len(days); d++ {
+		if d == days[next] {
+			snaps = append(snaps, snapshot{
+				Species: s.name,
+				Day:     d,
+				Timers:  p.timerCounts(),
+				Total:   p.total(),
+			})
+			next++
+		}
+		p = p.step(s)
+	}
+
+	return snaps
+}
+
+// getGrowth works the average rate out from the first and last snapshots, in
+// logs so totals far past a float64 still work
+func getGrowth(snaps []snapshot) growth {
+	first, last := snaps[0], snaps[len(snaps)-1]
+	g := growth{
+		species:  first.Species,
+		from:     first.Day,
+		to:       last.Day,
+		doubling: math.Inf(1),
+	}
+	if g.to == g.from || first.Total.Sign() == 0 || last.Total.Sign() == 0 {
+		return g
+	}
+
+	perDay := (logBig(last.Total) - logBig(first.Total)) / float64(g.to-g.from)
+	g.rate = math.Expm1(perDay)
+	if perDay > 0 {
+		g.doubling = math.Ln2 / perDay
+	}
+
+	return g
+}
+
+// logBig is the natural log of a positive big int
+func logBig(n *big.Int) float64 {
+	f := new(big.Float).SetInt(n)
+	mant := new(big.Float)
+	exp := f.MantExp(mant)
+	m, _ := mant.Float64()
+
+	return math.Log(m) + float64(exp)*math.Ln2
+}
+
+// writeAllSeries writes the series of every species to stdout and how fast
+// each one grew to stderr, so the series can be piped on its own
+func writeAllSeries(all []species, input []int, format string, report string) error {
+	days, err := getDays(report)
+	if err != nil {
+		return err
+	}
+
+	var snaps []snapshot
+	for _, s := range all {
+		if err := s.validate(); err != nil {
+			return err
+		}
+		counts, err := s.start(input)
+		if err != nil {
+			return err
+		}
+
+		ss := series(s, counts, days)
+		if err := writeGrowth(os.Stderr, getGrowth(ss)); err != nil {
+			return err
+		}
+		snaps = append(snaps, ss...)
+	}
+
+	return writeSeries(os.Stdout, format, snaps)
+}
+
+func writeSeries(w io.Writer, format string, snaps []snapshot) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(snaps); err != nil {
+			return fmt.Errorf("unable to encode series: %w", err)
+		}
+	case "csv":
+		// species can have different # of timers, so size the header for
+		// the one with the most
+		var timers int
+		for _, s := range snaps {
+			if len(s.Timers) > timers {
+				timers = len(s.Timers)
+			}
+		}
+
+		cw := csv.NewWriter(w)
+		header := []string{"species", "day"}
+		for t := 0; t < timers; t++ {
+			header = append(header, "timer "+strconv.Itoa(t))
+		}
+		header = append(header, "total")
+		if err := cw.Write(header); err != nil {
+			return fmt.Errorf("unable to write series: %w", err)
+		}
+
+		for _, s := range snaps {
+			record := []string{s.Species, strconv.Itoa(s.Day)}
+			for t := 0; t < timers; t++ {
+				count := ""
+				if t < len(s.Timers) {
+					count = s.Timers[t].String()
+				}
+				record = append(record, count)
+			}
+			record = append(record, s.Total.String())
+			if err := cw.Write(record); err != nil {
+				return fmt.Errorf("unable to write series: %w", err)
+			}
+		}
+
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return fmt.Errorf("unable to write series: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported series format: %s", format)
+	}
+
+	return nil
+}
+
+func writeGrowth(w io.Writer, g growth) error {
+	doubling := "never"
+	if !math.IsInf(g.doubling, 1) {
+		doubling = fmt.Sprintf("%.2f days", g.doubling)
+	}
+
+	_, err := fmt.Fprintf(w, "%s: days %d-%d, growth: %.4f%%/day, doubling time: %s\n",
+		g.species, g.from, g.to, g.rate*100, doubling)
+	if err != nil {
+		return fmt.Errorf("unable to write growth: %w", err)
+	}
+
+	return nil
+}
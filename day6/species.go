@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// species describes how a kind of fish lives. The puzzle's lanternfish spawn
+// every 7 days, so they go back to a timer of 6, their young need 2 more days
+// before their first cycle, so they start at 8, they have one fish per spawn
+// and never die.
+type species struct {
+	name string
+	// cycle is the # of days between spawns
+	cycle int
+	// juvenile is the # of extra days before a new fish's first spawn
+	juvenile int
+	// offspring is the # of new fish per spawn
+	offspring int
+	// deathAge is the # of days a fish lives, 0 for forever. the starting
+	// fish are taken to be newborns
+	deathAge int
+	// fish are the starting timers, the puzzle input is used when empty
+	fish []int
+}
+
+var lanternfish = species{name: "lanternfish", cycle: 7, juvenile: 2, offspring: 1}
+
+func (s species) timers() int {
+	return s.cycle + s.juvenile
+}
+
+// ages are only tracked when fish die of old age
+func (s species) ages() int {
+	if s.deathAge == 0 {
+		return 1
+	}
+
+	return s.deathAge
+}
+
+func (s species) validate() error {
+	switch {
+	case s.cycle < 1:
+		return fmt.Errorf("%s: cycle has to be at least 1 day", s.name)
+	case s.juvenile < 0:
+		return fmt.Errorf("%s: juvenile delay can't be negative", s.name)
+	case s.offspring < 0:
+		return fmt.Errorf("%s: offspring can't be negative", s.name)
+	case s.deathAge < 0:
+		return fmt.Errorf("%s: death age can't be negative", s.name)
+	}
+
+	return nil
+}
+
+// start is the histogram of the starting timers, made from the species' own
+// fish when it has them and the puzzle input otherwise
+func (s species) start(input []int) ([]int, error) {
+	counts := input
+	if len(s.fish) > 0 {
+		counts = make([]int, s.timers())
+		for _, t := range s.fish {
+			if t < 0 || t >= s.timers() {
+				return nil, fmt.Errorf("%s: timers go from 0 to %d, got %d", s.name, s.timers()-1, t)
+			}
+			counts[t]++
+		}
+	}
+
+	for t := s.timers(); t < len(counts); t++ {
+		if counts[t] > 0 {
+			return nil, fmt.Errorf("%s: timers go from 0 to %d, got %d", s.name, s.timers()-1, t)
+		}
+	}
+
+	return counts, nil
+}
+
+// population is a histogram of fish by timer and, for species that die, age
+type population [][]*big.Int
+
+func newPopulation(s species) population {
+	p := make(population, s.timers())
+	for t := range p {
+		p[t] = make([]*big.Int, s.ages())
+		for a := range p[t] {
+			p[t][a] = new(big.Int)
+		}
+	}
+
+	return p
+}
+
+func (p population) total() *big.Int {
+	sum := new(big.Int)
+	for t := range p {
+		for a := range p[t] {
+			sum.Add(sum, p[t][a])
+		}
+	}
+
+	return sum
+}
+
+// simulateSpecies runs the species day by day from the starting histogram
+func simulateSpecies(s species, counts []int, days int) population {
+	p := startPopulation(s, counts)
+	for d := 0; d < days; d++ {
+		p = p.step(s)
+	}
+
+	return p
+}
+
+// the starting fish are all taken to be newborns
+func startPopulation(s species, counts []int) population {
+	p := newPopulation(s)
+	for t, n := range counts {
+		if n > 0 {
+			p[t][0].SetInt64(int64(n))
+		}
+	}
+
+	return p
+}
+
+// step works out the next day. every fish ages a day, fish at 0 spawn and go
+// back to the start of their cycle, and fish that reach their death age die
+// once the day's spawning is done
+func (p population) step(s species) population {
+	offspring := big.NewInt(int64(s.offspring))
+	var spawned big.Int
+
+	next := newPopulation(s)
+	for t := range p {
+		for a := range p[t] {
+			c := p[t][a]
+			if c.Sign() == 0 {
+				continue
+			}
+
+			timer := t - 1
+			if t == 0 {
+				timer = s.cycle - 1
+				spawned.Mul(c, offspring)
+				next[s.timers()-1][0].Add(next[s.timers()-1][0], &spawned)
+			}
+
+			age := a
+			if s.deathAge > 0 {
+				age++
+				if age >= s.deathAge {
+					continue
+				}
+			}
+			next[timer][age].Add(next[timer][age], c)
+		}
+	}
+
+	return next
+}
+
+// timerCounts sums the ages up into a count per timer
+func (p population) timerCounts() []*big.Int {
+	counts := make([]*big.Int, len(p))
+	for t := range p {
+		counts[t] = new(big.Int)
+		for a := range p[t] {
+			counts[t].Add(counts[t], p[t][a])
+		}
+	}
+
+	return counts
+}
+
+// simulateAll runs every species side by side and prints each one's population
+// on the given days, followed by the total when there's more than one species
+func simulateAll(all []species, input []int, days []int) error {
+	totals := make([]*big.Int, len(days))
+	for i := range totals {
+		totals[i] = new(big.Int)
+	}
+
+	for _, s := range all {
+		if err := s.validate(); err != nil {
+			return err
+		}
+		counts, err := s.start(input)
+		if err != nil {
+			return err
+		}
+
+		var report []string
+		for i, d := range days {
+			if d <= 0 {
+				continue
+			}
+			n := simulateSpecies(s, counts, d).total()
+			totals[i].Add(totals[i], n)
+			report = append(report, fmt.Sprintf("day %d: %s", d, n))
+		}
+		fmt.Printf("%s: %s\n", s.name, strings.Join(report, ", "))
+	}
+
+	if len(all) > 1 {
+		var report []string
+		for i, d := range days {
+			if d > 0 {
+				report = append(report, fmt.Sprintf("day %d: %s", d, totals[i]))
+			}
+		}
+		fmt.Printf("all species: %s\n", strings.Join(report, ", "))
+	}
+
+	return nil
+}
+
+// getSpecies reads a species per line, fields are key=value pairs:
+//
+//	# the puzzle's fish
+//	name=lanternfish cycle=7 juvenile=2 offspring=1
+//	name=shortlived cycle=5 juvenile=1 offspring=2 death=30 fish=1,2,3
+func getSpecies(path string) ([]species, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open file: %w", err)
+	}
+	defer f.Close()
+
+	var all []species
+	s := bufio.NewScanner(f)
+	var line int
+	for s.Scan() {
+		line++
+		l := strings.TrimSpace(s.Text())
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+
+		sp := lanternfish
+		sp.name = fmt.Sprintf("species %d", len(all)+1)
+		for _, field := range strings.Fields(l) {
+			parts := strings.SplitN(field, "=", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("line %d: expected key=value, got %s", line, field)
+			}
+			if err := sp.set(parts[0], parts[1]); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+		all = append(all, sp)
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("encountered error while scanning: %w", err)
+	}
+
+	return all, nil
+}
+
+func (s *species) set(key, value string) error {
+	if key == "name" {
+		s.name = value
+		return nil
+	}
+
+	if key == "fish" {
+		s.fish = nil
+		for _, str := range strings.Split(value, ",") {
+			n, err := strconv.Atoi(str)
+			if err != nil {
+				return fmt.Errorf("unable to convert fish timer: %w", err)
+			}
+			s.fish = append(s.fish, n)
+		}
+		return nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("unable to convert %s: %w", key, err)
+	}
+
+	switch key {
+	case "cycle":
+		s.cycle = n
+	case "juvenile":
+		s.juvenile = n
+	case "offspring":
+		s.offspring = n
+	case "death":
+		s.deathAge = n
+	default:
+		return fmt.Errorf("unsupported key: %s", key)
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestStep follows a species with a juvenile delay, several offspring per
+// spawn and a death age through a few days worked out by hand
+func TestStep(t *testing.T) {
+	s := species{name: "test", cycle: 2, juvenile: 1, offspring: 3, deathAge: 3}
+	// want[d][timer][age] is the population after d+1 days
+	want := [][][]int64{
+		// the fish at 0 spawns 3 young at 2 and goes back to 1
+		{{0, 0, 0}, {0, 1, 0}, {3, 0, 0}},
+		// the young wait an extra day before their first cycle
+		{{0, 0, 1}, {0, 3, 0}, {0, 0, 0}},
+		// the first fish spawns once more and dies of old age
+		{{0, 0, 3}, {0, 0, 0}, {3, 0, 0}},
+		{{0, 0, 0}, {0, 3, 0}, {9, 0, 0}},
+	}
+
+	p := startPopulation(s, []int{1})
+	for d := range want {
+		p = p.step(s)
+		got := make([][]int64, len(p))
+		for timer := range p {
+			for age := range p[timer] {
+				got[timer] = append(got[timer], p[timer][age].Int64())
+			}
+		}
+		if !reflect.DeepEqual(got, want[d]) {
+			t.Fatalf("day %d: got %v, want %v", d+1, got, want[d])
+		}
+	}
+}
+
+func TestGetSpecies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "species.txt")
+	config := "# the puzzle's fish\n" +
+		"name=lanternfish cycle=7 juvenile=2 offspring=1\n" +
+		"\n" +
+		"cycle=5 juvenile=1 offspring=2 death=30 fish=1,2,3\n"
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatalf("unable to write config: %v", err)
+	}
+
+	got, err := getSpecies(path)
+	if err != nil {
+		t.Fatalf("unable to get species: %v", err)
+	}
+	want := []species{
+		lanternfish,
+		{name: "species 2", cycle: 5, juvenile: 1, offspring: 2, deathAge: 30, fish: []int{1, 2, 3}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	for _, bad := range []string{"cycle", "cycle=x", "colour=red", "fish=1,,2"} {
+		if err := os.WriteFile(path, []byte(bad+"\n"), 0o644); err != nil {
+			t.Fatalf("unable to write config: %v", err)
+		}
+		if _, err := getSpecies(path); err == nil {
+			t.Errorf("%s: expected an error", bad)
+		}
+	}
+}
@@ -0,0 +1,394 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// distribution is the chance of each spawn interval, pmf[i] is the chance a
+// fish waits i+1 days between spawns
+type distribution []float64
+
+// getDistribution parses the spawn interval distribution:
+//
+//	7                    always 7 days
+//	uniform:6-8          6, 7 or 8 days with the same chance
+//	normal:7,1           a normal rounded to whole days
+//	poisson:7            a poisson, shifted so there's at least a day
+//	weights:6=1,7=3,8=1  relative weights of each interval
+//
+// normal and poisson are cut off once the tail is negligible.
+func getDistribution(spec string) (distribution, error) {
+	parts := strings.SplitN(spec, ":", 2)
+	if len(parts) == 1 {
+		n, err := strconv.Atoi(spec)
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert interval: %w", err)
+		}
+		return weighted(map[int]float64{n: 1})
+	}
+
+	weights := make(map[int]float64)
+	args := parts[1]
+	switch parts[0] {
+	case "uniform":
+		bounds := strings.SplitN(args, "-", 2)
+		if len(bounds) != 2 {
+			return nil, fmt.Errorf("expected uniform:min-max, got %s", spec)
+		}
+		lo, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert min: %w", err)
+		}
+		hi, err := strconv.Atoi(bounds[1])
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert max: %w", err)
+		}
+		for i := lo; i <= hi; i++ {
+			weights[i] = 1
+		}
+	case "normal":
+		params := strings.SplitN(args, ",", 2)
+		if len(params) != 2 {
+			return nil, fmt.Errorf("expected normal:mean,stddev, got %s", spec)
+		}
+		mean, err := strconv.ParseFloat(params[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert mean: %w", err)
+		}
+		sd, err := strconv.ParseFloat(params[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert stddev: %w", err)
+		}
+		if sd <= 0 {
+			return nil, fmt.Errorf("stddev has to be positive")
+		}
+		for i := int(math.Max(1, math.Floor(mean-6*sd))); float64(i) <= mean+6*sd; i++ {
+			// the chance of rounding to i
+			weights[i] = normalCDF(float64(i)+0.5, mean, sd) - normalCDF(float64(i)-0.5, mean, sd)
+		}
+	case "poisson":
+		mean, err := strconv.ParseFloat(args, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert mean: %w", err)
+		}
+		if mean < 1 {
+			return nil, fmt.Errorf("mean has to be at least 1 day")
+		}
+		lambda := mean - 1
+		p := math.Exp(-lambda)
+		for k := 0; float64(k) <= lambda+10*math.Sqrt(lambda)+10; k++ {
+			weights[k+1] = p
+			p *= lambda / float64(k+1)
+		}
+	case "weights":
+		for _, field := range strings.Split(args, ",") {
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("expected interval=weight, got %s", field)
+			}
+			i, err := strconv.Atoi(kv[0])
+			if err != nil {
+				return nil, fmt.Errorf("unable to convert interval: %w", err)
+			}
+			w, err := strconv.ParseFloat(kv[1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("unable to convert weight: %w", err)
+			}
+			weights[i] += w
+		}
+	default:
+		return nil, fmt.Errorf("unsupported distribution: %s", parts[0])
+	}
+
+	return weighted(weights)
+}
+
+func weighted(weights map[int]float64) (distribution, error) {
+	var max int
+	var sum float64
+	for i, w := range weights {
+		if i < 1 && w > 0 {
+			return nil, fmt.Errorf("intervals have to be at least 1 day, got %d", i)
+		}
+		if w < 0 {
+			return nil, fmt.Errorf("weights can't be negative")
+		}
+		if w > 0 && i > max {
+			max = i
+		}
+		sum += w
+	}
+	if sum == 0 {
+		return nil, fmt.Errorf("no interval has a chance")
+	}
+
+	pmf := make(distribution, max)
+	for i, w := range weights {
+		if w > 0 {
+			pmf[i-1] = w / sum
+		}
+	}
+
+	return pmf, nil
+}
+
+func normalCDF(x, mean, sd float64) float64 {
+	return 0.5 * math.Erfc(-(x-mean)/(sd*math.Sqrt2))
+}
+
+// stochastic describes a species whose fish don't all behave the same. every
+// spawn draws the parent's next interval and each child's first one from the
+// distribution, children wait juvenile days more, and every day each fish
+// dies with the chance of mortality.
+type stochastic struct {
+	interval  distribution
+	juvenile  int
+	offspring int
+	mortality float64
+}
+
+// timers is the highest timer a fish can have plus one
+func (s stochastic) timers() int {
+	return len(s.interval) + s.juvenile
+}
+
+// limit keeps the counts well inside an int64, the normal approximation of
+// the binomial stops being accurate long before that anyway
+const limit = 1 << 60
+
+// replicate runs the fish once, counts are plain ints since every fish is
+// drawn. totals[i] is the population on days[i]
+func (s stochastic) replicate(rng *rand.Rand, start []int, days []int) ([]int64, error) {
+	counts := make([]int64, s.timers())
+	for t, n := range start {
+		if n == 0 {
+			continue
+		}
+		if t >= len(counts) {
+			return nil, fmt.Errorf("timers go from 0 to %d, got %d", len(counts)-1, t)
+		}
+		counts[t] += int64(n)
+	}
+
+	totals := make([]int64, len(days))
+	next := 0
+	for d := 0; next < len(days); d++ {
+		if d == days[next] {
+			for _, n := range counts {
+				totals[next] += n
+			}
+			next++
+			if next == len(days) {
+				break
+			}
+		}
+
+		if s.mortality > 0 {
+			for t := range counts {
+				counts[t] -= binomial(rng, counts[t], s.mortality)
+			}
+		}
+
+		spawning := counts[0]
+		copy(counts, counts[1:])
+		counts[len(counts)-1] = 0
+
+		parents := multinomial(rng, spawning, s.interval)
+		children := multinomial(rng, spawning*int64(s.offspring), s.interval)
+		for i := range s.interval {
+			counts[i] += parents[i]
+			counts[i+s.juvenile] += children[i]
+			if counts[i] > limit || counts[i+s.juvenile] > limit {
+				return nil, fmt.Errorf("population is too big to draw on day %d", d+1)
+			}
+		}
+	}
+
+	return totals, nil
+}
+
+// multinomial splits n fish over the intervals one binomial at a time
+func multinomial(rng *rand.Rand, n int64, pmf distribution) []int64 {
+	split := make([]int64, len(pmf))
+	rest := 1.0
+	for i, p := range pmf {
+		if n == 0 {
+			break
+		}
+		if i == len(pmf)-1 || p >= rest {
+			split[i] = n
+			break
+		}
+		split[i] = binomial(rng, n, p/rest)
+		n -= split[i]
+		rest -= p
+	}
+
+	return split
+}
+
+// binomial draws how many of n fish something happens to. few fish are drawn
+// one by one, rare or near certain events come from a poisson and everything
+// else from a normal approximation
+func binomial(rng *rand.Rand, n int64, p float64) int64 {
+	switch {
+	case n == 0 || p <= 0:
+		return 0
+	case p >= 1:
+		return n
+	case n < 64:
+		var k int64
+		for i := int64(0); i < n; i++ {
+			if rng.Float64() < p {
+				k++
+			}
+		}
+		return k
+	case float64(n)*p < 10:
+		return minInt64(n, poisson(rng, float64(n)*p))
+	case float64(n)*(1-p) < 10:
+		return n - minInt64(n, poisson(rng, float64(n)*(1-p)))
+	}
+
+	mean := float64(n) * p
+	k := int64(math.Round(mean + rng.NormFloat64()*math.Sqrt(mean*(1-p))))
+	if k < 0 {
+		return 0
+	}
+
+	return minInt64(n, k)
+}
+
+// poisson counts arrivals until their product drops below e^-lambda, only
+// used for small lambdas
+func poisson(rng *rand.Rand, lambda float64) int64 {
+	l := math.Exp(-lambda)
+	var k int64
+	for p := rng.Float64(); p > l; p *= rng.Float64() {
+		k++
+	}
+
+	return k
+}
+
+func minInt64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+// spread is the population over every replicate on one day
+type spread struct {
+	day         int
+	mean        float64
+	percentiles []int64
+}
+
+// runReplicates runs the replicates over the workers. like the day4 monte
+// carlo, each replicate seeds its own rng off of its # so the results only
+// depend on the seed
+func runReplicates(s stochastic, start []int, days []int, replicates, workers int, seed int64, percentiles []float64) ([]spread, error) {
+	results := make([][]int64, replicates)
+	errs := make([]error, replicates)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range jobs {
+				rng := rand.New(rand.NewSource(seed + int64(r)))
+				results[r], errs[r] = s.replicate(rng, start, days)
+			}
+		}()
+	}
+
+	for r := 0; r < replicates; r++ {
+		jobs <- r
+	}
+	close(jobs)
+	wg.Wait()
+
+	for r, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("replicate %d: %w", r+1, err)
+		}
+	}
+
+	spreads := make([]spread, len(days))
+	totals := make([]int64, replicates)
+	for i, d := range days {
+		var sum float64
+		for r := range results {
+			totals[r] = results[r][i]
+			sum += float64(totals[r])
+		}
+		sort.Slice(totals, func(a, b int) bool { return totals[a] < totals[b] })
+
+		spreads[i] = spread{day: d, mean: sum / float64(replicates)}
+		for _, p := range percentiles {
+			spreads[i].percentiles = append(spreads[i].percentiles, nearestRank(totals, p))
+		}
+	}
+
+	return spreads, nil
+}
+
+// nearestRank is the smallest total that at least p% of them are at or below
+func nearestRank(sorted []int64, p float64) int64 {
+	i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+
+	return sorted[i]
+}
+
+func getPercentiles(spec string) ([]float64, error) {
+	var ps []float64
+	for _, field := range strings.Split(spec, ",") {
+		p, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert percentile: %w", err)
+		}
+		if p < 0 || p > 100 {
+			return nil, fmt.Errorf("percentiles go from 0 to 100, got %v", p)
+		}
+		ps = append(ps, p)
+	}
+
+	return ps, nil
+}
+
+func writeSpreads(w io.Writer, spreads []spread, percentiles []float64) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "day\tmean\t")
+	for _, p := range percentiles {
+		fmt.Fprintf(tw, "p%v\t", p)
+	}
+	fmt.Fprintln(tw)
+
+	for _, s := range spreads {
+		fmt.Fprintf(tw, "%d\t%.1f\t", s.day, s.mean)
+		for _, n := range s.percentiles {
+			fmt.Fprintf(tw, "%d\t", n)
+		}
+		fmt.Fprintln(tw)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("unable to write spreads: %w", err)
+	}
+
+	return nil
+}
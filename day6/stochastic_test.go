@@ -0,0 +1,126 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// TestFixedInterval makes sure random fish that always wait 7 days and never
+// die come out the same as the puzzle's fish in every replicate
+func TestFixedInterval(t *testing.T) {
+	pmf, err := getDistribution("7")
+	if err != nil {
+		t.Fatalf("unable to get distribution: %v", err)
+	}
+	nums, err := parseFish("3,4,3,1,2")
+	if err != nil {
+		t.Fatalf("unable to parse fish: %v", err)
+	}
+
+	s := stochastic{interval: pmf, juvenile: 2, offspring: 1}
+	days := []int{18, 80, 256}
+	want := []int64{26, 5934, 26984457539}
+	spreads, err := runReplicates(s, nums, days, 20, 4, 1, []float64{0, 100})
+	if err != nil {
+		t.Fatalf("unable to run replicates: %v", err)
+	}
+	for i, sp := range spreads {
+		if sp.mean != float64(want[i]) || sp.percentiles[0] != want[i] || sp.percentiles[1] != want[i] {
+			t.Errorf("day %d: got mean %v, range %v, want %d", days[i], sp.mean, sp.percentiles, want[i])
+		}
+	}
+}
+
+func TestStochasticDeath(t *testing.T) {
+	custom := lanternfish
+	custom.deathAge = 30
+	if err := runStochastic([]int{1}, "7", custom, 0, "0-10", "50", 1, 1, 1); err == nil {
+		t.Errorf("expected an error for a death age")
+	}
+}
+
+func TestGetDistribution(t *testing.T) {
+	tests := []struct {
+		spec string
+		// want is only checked when set, every pmf has to add up to 1
+		want distribution
+	}{
+		{spec: "7", want: distribution{0, 0, 0, 0, 0, 0, 1}},
+		{spec: "uniform:2-3", want: distribution{0, 0.5, 0.5}},
+		{spec: "weights:1=1,3=3", want: distribution{0.25, 0, 0.75}},
+		{spec: "weights:2=2,2=2,1=0", want: distribution{0, 1}},
+		{spec: "normal:7,1"},
+		{spec: "poisson:7"},
+		{spec: "poisson:1", want: distribution{1}},
+	}
+
+	for _, tt := range tests {
+		got, err := getDistribution(tt.spec)
+		if err != nil {
+			t.Errorf("%s: unable to get distribution: %v", tt.spec, err)
+			continue
+		}
+
+		var sum float64
+		for _, p := range got {
+			sum += p
+		}
+		if math.Abs(sum-1) > 1e-9 {
+			t.Errorf("%s: chances add up to %v", tt.spec, sum)
+		}
+
+		if tt.want == nil {
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.spec, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if math.Abs(got[i]-tt.want[i]) > 1e-9 {
+				t.Errorf("%s: got %v, want %v", tt.spec, got, tt.want)
+				break
+			}
+		}
+	}
+
+	for _, spec := range []string{
+		"x",
+		"0",
+		"uniform:0-2",
+		"uniform:3-2",
+		"uniform:3",
+		"normal:7",
+		"normal:7,0",
+		"poisson:0.5",
+		"weights:7",
+		"weights:7=-1,8=2",
+		"weights:7=0",
+		"gamma:7",
+	} {
+		if _, err := getDistribution(spec); err == nil {
+			t.Errorf("%s: expected an error", spec)
+		}
+	}
+}
+
+func TestNearestRank(t *testing.T) {
+	sorted := []int64{10, 20, 30, 40, 50}
+	tests := []struct {
+		p    float64
+		want int64
+	}{
+		{p: 0, want: 10},
+		{p: 20, want: 10},
+		{p: 21, want: 20},
+		{p: 50, want: 30},
+		{p: 95, want: 50},
+		{p: 100, want: 50},
+	}
+
+	for _, tt := range tests {
+		if got := nearestRank(sorted, tt.p); got != tt.want {
+			t.Errorf("p%v: got %d, want %d", tt.p, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// cost is how much fuel crabs spend to move. convex costs never go down and
+// then back up again over the positions, which is what lets the optimizer
+// narrow the search down instead of trying every position
+type cost struct {
+	step   stepper
+	convex bool
+	// perCrab costs look at where a crab is and not only how far it goes,
+	// so they only make sense on a line
+	perCrab bool
+}
+
+// costs are the fixed cost functions by name, part1 and part2 are the
+// puzzle's names for linear and triangular
+var costs = map[string]cost{
+	"linear": {
+		step: func(alignAt, pos, count int) int {
+			return absSub(alignAt, pos) * count
+		},
+		convex: true,
+	},
+	"triangular": {
+		step: func(alignAt, pos, count int) int {
+			steps := absSub(alignAt, pos)
+			// n(n+1)/2
+			return ((steps * (steps + 1)) / 2) * count
+		},
+		convex: true,
+	},
+	"quadratic": {
+		step: func(alignAt, pos, count int) int {
+			steps := absSub(alignAt, pos)
+			return steps * steps * count
+		},
+		convex: true,
+	},
+}
+
+func init() {
+	costs["part1"] = costs["linear"]
+	costs["part2"] = costs["triangular"]
+}
+
+// getCost looks the cost up by name, or builds it from its arguments:
+//
+//	capped:N                  linear, but no crab spends more than N
+//	weighted:BASE:POS=W,...   the crabs at POS spend W times the base cost,
+//	                          the rest spend it once
+//	expr:EXPRESSION           an expression of the steps d, e.g. d*d+2*d, with
+//	                          + - * / ^, parentheses, abs, min and max
+func getCost(name string) (cost, error) {
+	if c, ok := costs[name]; ok {
+		return c, nil
+	}
+
+	parts := strings.SplitN(name, ":", 2)
+	if len(parts) != 2 {
+		return cost{}, fmt.Errorf("unsupported cost: %s", name)
+	}
+
+	switch parts[0] {
+	case "capped":
+		limit, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return cost{}, fmt.Errorf("unable to convert cap: %w", err)
+		}
+		return cost{
+			step: func(alignAt, pos, count int) int {
+				steps := absSub(alignAt, pos)
+				if steps > limit {
+					steps = limit
+				}
+				return steps * count
+			},
+			// flat past the cap, so the total can have more than one dip
+			convex: false,
+		}, nil
+	case "weighted":
+		return getWeighted(parts[1])
+	case "expr":
+		f, err := parseExpr(parts[1])
+		if err != nil {
+			return cost{}, fmt.Errorf("unable to parse expression: %w", err)
+		}
+		return cost{
+			step: func(alignAt, pos, count int) int {
+				return f(absSub(alignAt, pos)) * count
+			},
+			// no telling what an expression looks like
+			convex: false,
+		}, nil
+	default:
+		return cost{}, fmt.Errorf("unsupported cost: %s", parts[0])
+	}
+}
+
+func getWeighted(args string) (cost, error) {
+	parts := strings.SplitN(args, ":", 2)
+	if len(parts) != 2 {
+		return cost{}, fmt.Errorf("expected weighted:base:pos=weight,..., got weighted:%s", args)
+	}
+
+	base, ok := costs[parts[0]]
+	if !ok {
+		return cost{}, fmt.Errorf("unsupported base cost: %s", parts[0])
+	}
+
+	weights := make(map[int]int)
+	convex := base.convex
+	for _, field := range strings.Split(parts[1], ",") {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return cost{}, fmt.Errorf("expected pos=weight, got %s", field)
+		}
+		pos, err := strconv.Atoi(kv[0])
+		if err != nil {
+			return cost{}, fmt.Errorf("unable to convert position: %w", err)
+		}
+		w, err := strconv.Atoi(kv[1])
+		if err != nil {
+			return cost{}, fmt.Errorf("unable to convert weight: %w", err)
+		}
+		// a negative weight turns that crab's cost upside down
+		convex = convex && w >= 0
+		weights[pos] = w
+	}
+
+	return cost{
+		step: func(alignAt, pos, count int) int {
+			w, ok := weights[pos]
+			if !ok {
+				w = 1
+			}
+			return base.step(alignAt, pos, count) * w
+		},
+		convex:  convex,
+		perCrab: true,
+	}, nil
+}
+
+// optimize finds the cheapest position between the outermost crabs. convex
+// costs use a ternary search, every other cost tries every position. ties go
+// to the lowest position searched
+func optimize(c cost, nums map[int]int) (int, int, error) {
+	if len(nums) == 0 {
+		return 0, 0, fmt.Errorf("no crabs to align")
+	}
+
+	lo, hi := math.MaxInt, math.MinInt
+	for pos := range nums {
+		if pos < lo {
+			lo = pos
+		}
+		if pos > hi {
+			hi = pos
+		}
+	}
+
+	if c.convex {
+		// a convex total has a minimum between two positions that cost the
+		// same, and none past the more expensive of two positions
+		for hi-lo > 2 {
+			m1, m2 := lo+(hi-lo)/3, hi-(hi-lo)/3
+			f1, f2 := fuelAt(c.step, nums, m1), fuelAt(c.step, nums, m2)
+			switch {
+			case f1 < f2:
+				hi = m2 - 1
+			case f1 > f2:
+				lo = m1 + 1
+			default:
+				lo, hi = m1, m2
+			}
+		}
+	}
+
+	return scan(c.step, nums, lo, hi)
+}
+
+// scan tries every position in [lo, hi]
+func scan(step stepper, nums map[int]int, lo, hi int) (int, int, error) {
+	minFuel, minPos := math.MaxInt, lo
+	for i := lo; i <= hi; i++ {
+		if f := fuelAt(step, nums, i); f < minFuel {
+			minFuel, minPos = f, i
+		}
+	}
+
+	return minFuel, minPos, nil
+}
+
+// expr is an expression of the # of steps
+type expr func(d int) int
+
+// parseExpr is a recursive descent over
+//
+//	sum     = product { ("+" | "-") product }
+//	product = unary { ("*" | "/") unary }
+//	unary   = "-" unary | power
+//	power   = atom [ "^" unary ]
+//	atom    = number | "d" | "(" sum ")" | func "(" sum { "," sum } ")"
+func parseExpr(s string) (expr, error) {
+	p := &exprParser{}
+	for i := 0; i < len(s); {
+		r := rune(s[i])
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || unicode.IsLetter(r):
+			j := i
+			for j < len(s) && (unicode.IsDigit(rune(s[j])) || unicode.IsLetter(rune(s[j]))) {
+				j++
+			}
+			p.tokens = append(p.tokens, s[i:j])
+			i = j
+		case strings.ContainsRune("+-*/^(),", r):
+			p.tokens = append(p.tokens, string(r))
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected character %q", r)
+		}
+	}
+
+	e, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	if p.i < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %s", p.tokens[p.i])
+	}
+
+	return e, nil
+}
+
+type exprParser struct {
+	tokens []string
+	i      int
+}
+
+func (p *exprParser) peek() string {
+	if p.i < len(p.tokens) {
+		return p.tokens[p.i]
+	}
+
+	return ""
+}
+
+func (p *exprParser) expect(tok string) error {
+	if p.peek() != tok {
+		return fmt.Errorf("expected %s, got %q", tok, p.peek())
+	}
+	p.i++
+
+	return nil
+}
+
+func (p *exprParser) sum() (expr, error) {
+	left, err := p.product()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == "+" || p.peek() == "-" {
+		op := p.peek()
+		p.i++
+		right, err := p.product()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		if op == "+" {
+			left = func(d int) int { return l(d) + right(d) }
+		} else {
+			left = func(d int) int { return l(d) - right(d) }
+		}
+	}
+
+	return left, nil
+}
+
+func (p *exprParser) product() (expr, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == "*" || p.peek() == "/" {
+		op := p.peek()
+		p.i++
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		if op == "*" {
+			left = func(d int) int { return l(d) * right(d) }
+		} else {
+			// dividing by 0 costs nothing rather than crashing mid search
+			left = func(d int) int {
+				if r := right(d); r != 0 {
+					return l(d) / r
+				}
+				return 0
+			}
+		}
+	}
+
+	return left, nil
+}
+
+func (p *exprParser) power() (expr, error) {
+	base, err := p.atom()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek() != "^" {
+		return base, nil
+	}
+	p.i++
+	exp, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(d int) int {
+		n := 1
+		for e := exp(d); e > 0; e-- {
+			n *= base(d)
+		}
+		return n
+	}, nil
+}
+
+func (p *exprParser) unary() (expr, error) {
+	if p.peek() == "-" {
+		p.i++
+		e, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(d int) int { return -e(d) }, nil
+	}
+
+	return p.power()
+}
+
+func (p *exprParser) atom() (expr, error) {
+	tok := p.peek()
+	p.i++
+	switch {
+	case tok == "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case tok == "d":
+		return func(d int) int { return d }, nil
+	case tok == "(":
+		e, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		return e, p.expect(")")
+	case tok == "abs" || tok == "min" || tok == "max":
+		args, err := p.args()
+		if err != nil {
+			return nil, err
+		}
+		return call(tok, args)
+	}
+
+	n, err := strconv.Atoi(tok)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected %s", tok)
+	}
+
+	return func(int) int { return n }, nil
+}
+
+func (p *exprParser) args() ([]expr, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	var args []expr
+	for {
+		e, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, e)
+		if p.peek() != "," {
+			break
+		}
+		p.i++
+	}
+
+	return args, p.expect(")")
+}
+
+func call(name string, args []expr) (expr, error) {
+	switch {
+	case name == "abs" && len(args) == 1:
+		return func(d int) int { return absSub(args[0](d), 0) }, nil
+	case name == "min" && len(args) == 2:
+		return func(d int) int { return minInt(args[0](d), args[1](d)) }, nil
+	case name == "max" && len(args) == 2:
+		return func(d int) int { return maxInt(args[0](d), args[1](d)) }, nil
+	default:
+		return nil, fmt.Errorf("%s doesn't take %d arguments", name, len(args))
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
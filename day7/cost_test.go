@@ -0,0 +1,77 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// TestOptimizer makes sure the ternary search lands on the same fuel as
+// trying every position for the convex costs on random inputs
+func TestOptimizer(t *testing.T) {
+	names := make([]string, 0, len(costs))
+	for name := range costs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	names = append(names, "weighted:triangular:0=3,5=2,10=0", "capped:5", "expr:max(d-3,0)^2+d")
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < randomInputs; i++ {
+		nums := randomNums(rng, 1+rng.Intn(20), 1+rng.Intn(50))
+		for _, name := range names {
+			c, err := getCost(name)
+			if err != nil {
+				t.Fatalf("unable to get cost %s: %v", name, err)
+			}
+			got, pos, err := optimize(c, nums)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			lo, hi := math.MaxInt, math.MinInt
+			for p := range nums {
+				lo, hi = minInt(lo, p), maxInt(hi, p)
+			}
+			want, wantPos, _ := scan(c.step, nums, lo, hi)
+			if got != want {
+				t.Fatalf("%s: optimizer spent %d fuel at %d, full scan spent %d at %d in %v", name, got, pos, want, wantPos, nums)
+			}
+		}
+	}
+}
+
+func TestExpr(t *testing.T) {
+	tests := []struct {
+		expr string
+		d    int
+		want int
+	}{
+		{expr: "d", d: 4, want: 4},
+		{expr: "d*(d+1)/2", d: 4, want: 10},
+		{expr: "2+3*d", d: 2, want: 8},
+		{expr: "(2+3)*d", d: 2, want: 10},
+		{expr: "2^3^2", d: 0, want: 512},
+		{expr: "-d^2", d: 3, want: -9},
+		{expr: "10-d-1", d: 3, want: 6},
+		{expr: "min(d, 5) + max(d, 5) + abs(1-d)", d: 3, want: 10},
+		{expr: "d/0", d: 3, want: 0},
+	}
+
+	for _, tt := range tests {
+		f, err := parseExpr(tt.expr)
+		if err != nil {
+			t.Errorf("%s: unable to parse: %v", tt.expr, err)
+			continue
+		}
+		if got := f(tt.d); got != tt.want {
+			t.Errorf("%s at d=%d is %d, want %d", tt.expr, tt.d, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "2^", "(d", "d d", "min(d)", "x", "d % 2"} {
+		if _, err := parseExpr(bad); err == nil {
+			t.Errorf("%q parsed", bad)
+		}
+	}
+}
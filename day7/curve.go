@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// the ascii chart's size in characters, and the svg's in pixels
+const (
+	chartWidth  = 72
+	chartHeight = 20
+	svgWidth    = 800
+	svgHeight   = 400
+	svgMargin   = 40
+)
+
+// curve is the total fuel of one cost at every position between the
+// outermost crabs
+type curve struct {
+	name string
+	lo   int
+	fuel []int
+	// best is the index of the cheapest position, the lowest one on a tie
+	best int
+	// dips is the # of local minima, plateaus counted once. the scan's early
+	// break is only right when there's a single one
+	dips int
+	// convex is set when the fuel never goes up by less than it did the
+	// position before
+	convex bool
+}
+
+func (c curve) pos(i int) int {
+	return c.lo + i
+}
+
+func getCurve(name string, nums map[int]int) (curve, error) {
+	if len(nums) == 0 {
+		return curve{}, fmt.Errorf("no crabs to align")
+	}
+
+	step, err := getStepper(name)
+	if err != nil {
+		return curve{}, err
+	}
+
+	lo, hi := math.MaxInt, math.MinInt
+	for pos := range nums {
+		lo, hi = minInt(lo, pos), maxInt(hi, pos)
+	}
+
+	c := curve{name: name, lo: lo, fuel: make([]int, hi-lo+1), convex: true}
+	for i := range c.fuel {
+		c.fuel[i] = fuelAt(step, nums, c.pos(i))
+		if c.fuel[i] < c.fuel[c.best] {
+			c.best = i
+		}
+		if i > 1 && c.fuel[i]-c.fuel[i-1] < c.fuel[i-1]-c.fuel[i-2] {
+			c.convex = false
+		}
+	}
+
+	// a plateau is a dip when it's lower than both of its ends
+	for i := 0; i < len(c.fuel); {
+		j := i
+		for j+1 < len(c.fuel) && c.fuel[j+1] == c.fuel[i] {
+			j++
+		}
+		if (i == 0 || c.fuel[i-1] > c.fuel[i]) && (j == len(c.fuel)-1 || c.fuel[j+1] > c.fuel[j]) {
+			c.dips++
+		}
+		i = j + 1
+	}
+
+	return c, nil
+}
+
+// curveFile writes the curves of the costs, the puzzle's two by default, and
+// sums each one up on stderr
+func curveFile(path, format, costName string, nums map[int]int) error {
+	names := []string{"linear", "triangular"}
+	if costName != "" {
+		names = []string{costName}
+	}
+
+	var curves []curve
+	for _, name := range names {
+		c, err := getCurve(name, nums)
+		if err != nil {
+			return fmt.Errorf("unable to get curve: %w", err)
+		}
+		curves = append(curves, c)
+		fmt.Fprintf(os.Stderr, "%s: optimum at %d, fuel: %d, local minima: %d, convex: %t\n", c.name, c.pos(c.best), c.fuel[c.best], c.dips, c.convex)
+	}
+
+	var write func(w io.Writer, curves []curve) error
+	switch format {
+	case "csv":
+		write = writeCurveCSV
+	case "ascii":
+		write = writeCurveASCII
+	case "svg":
+		write = writeCurveSVG
+	default:
+		return fmt.Errorf("unsupported curve format: %s", format)
+	}
+
+	if path == "-" {
+		return write(os.Stdout, curves)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("unable to create file: %w", err)
+	}
+
+	if err := write(f, curves); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// writeCurveCSV writes a row per position, the optimum column lists the costs
+// that are cheapest there
+func writeCurveCSV(w io.Writer, curves []curve) error {
+	cw := csv.NewWriter(w)
+	header := []string{"position"}
+	for _, c := range curves {
+		header = append(header, c.name)
+	}
+	header = append(header, "optimum")
+	if err := cw.Write(header); err != nil {
+		return fmt.Errorf("unable to write curve: %w", err)
+	}
+
+	for i := range curves[0].fuel {
+		record := []string{strconv.Itoa(curves[0].pos(i))}
+		var best []string
+		for _, c := range curves {
+			record = append(record, strconv.Itoa(c.fuel[i]))
+			if i == c.best {
+				best = append(best, c.name)
+			}
+		}
+		record = append(record, strings.Join(best, " "))
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("unable to write curve: %w", err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("unable to write curve: %w", err)
+	}
+
+	return nil
+}
+
+// writeCurveASCII draws a chart per curve since their scales are far apart.
+// positions are bucketed into columns by their cheapest fuel, the column of
+// the optimum is drawn with a * and the rest with a #
+func writeCurveASCII(w io.Writer, curves []curve) error {
+	bw := bufio.NewWriter(w)
+	for n, c := range curves {
+		if n > 0 {
+			fmt.Fprintln(bw)
+		}
+
+		// each column is the cheapest position of its bucket, the bars are
+		// scaled on those so the most expensive column fills the chart
+		cols := minInt(chartWidth, len(c.fuel))
+		minima := make([]int, cols)
+		var best int
+		for col := range minima {
+			minima[col] = math.MaxInt
+			for i := col * len(c.fuel) / cols; i < (col+1)*len(c.fuel)/cols; i++ {
+				minima[col] = minInt(minima[col], c.fuel[i])
+				if i == c.best {
+					best = col
+				}
+			}
+		}
+		lo, hi := c.fuel[c.best], minima[0]
+		for _, m := range minima {
+			hi = maxInt(hi, m)
+		}
+
+		heights := make([]int, cols)
+		for col, m := range minima {
+			// the cheapest position still gets a row so it's visible
+			heights[col] = 1
+			if hi > lo {
+				heights[col] += (m - lo) * (chartHeight - 1) / (hi - lo)
+			}
+		}
+
+		fmt.Fprintf(bw, "%s, optimum at %d with %d fuel\n", c.name, c.pos(c.best), c.fuel[c.best])
+		for row := chartHeight; row > 0; row-- {
+			label := ""
+			switch row {
+			case chartHeight:
+				label = strconv.Itoa(hi)
+			case 1:
+				label = strconv.Itoa(lo)
+			}
+			fmt.Fprintf(bw, "%12s |", label)
+			for col, h := range heights {
+				switch {
+				case h < row:
+					bw.WriteByte(' ')
+				case col == best:
+					bw.WriteByte('*')
+				default:
+					bw.WriteByte('#')
+				}
+			}
+			bw.WriteByte('\n')
+		}
+		fmt.Fprintf(bw, "%12s +%s\n", "", strings.Repeat("-", cols))
+		first, last := strconv.Itoa(c.lo), strconv.Itoa(c.pos(len(c.fuel)-1))
+		if len(c.fuel) == 1 {
+			fmt.Fprintf(bw, "%12s  %s\n", "", first)
+		} else {
+			// the labels sit under the ends of the axis unless that would
+			// run them together
+			fmt.Fprintf(bw, "%12s  %-*s%s\n", "", maxInt(cols-len(last), len(first)+1), first, last)
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("unable to write chart: %w", err)
+	}
+
+	return nil
+}
+
+// colors of the curves in the svg, in order
+var curveColors = []string{"#1f77b4", "#d62728", "#2ca02c", "#9467bd"}
+
+// writeCurveSVG draws every curve on the same positions, each one scaled to
+// its own range of fuel, with a dot and a label on its optimum
+func writeCurveSVG(w io.Writer, curves []curve) error {
+	bw := bufio.NewWriter(w)
+	plotW, plotH := float64(svgWidth-2*svgMargin), float64(svgHeight-2*svgMargin)
+
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" font-family=\"monospace\" font-size=\"12\">\n", svgWidth, svgHeight)
+	fmt.Fprintf(bw, "<rect width=\"100%%\" height=\"100%%\" fill=\"white\"/>\n")
+	fmt.Fprintf(bw, "<line x1=\"%d\" y1=\"%d\" x2=\"%d\" y2=\"%d\" stroke=\"black\"/>\n", svgMargin, svgHeight-svgMargin, svgWidth-svgMargin, svgHeight-svgMargin)
+	fmt.Fprintf(bw, "<text x=\"%d\" y=\"%d\">%d</text>\n", svgMargin, svgHeight-svgMargin/2, curves[0].lo)
+	last := curves[0].pos(len(curves[0].fuel) - 1)
+	fmt.Fprintf(bw, "<text x=\"%d\" y=\"%d\" text-anchor=\"end\">%d</text>\n", svgWidth-svgMargin, svgHeight-svgMargin/2, last)
+
+	for n, c := range curves {
+		color := curveColors[n%len(curveColors)]
+		lo, hi := c.fuel[c.best], c.fuel[0]
+		for _, f := range c.fuel {
+			hi = maxInt(hi, f)
+		}
+		x := func(i int) float64 {
+			if len(c.fuel) == 1 {
+				return svgMargin + plotW/2
+			}
+			return svgMargin + plotW*float64(i)/float64(len(c.fuel)-1)
+		}
+		y := func(f int) float64 {
+			if hi == lo {
+				return svgMargin + plotH
+			}
+			return svgMargin + plotH*(1-float64(f-lo)/float64(hi-lo))
+		}
+
+		points := make([]string, len(c.fuel))
+		for i, f := range c.fuel {
+			points[i] = fmt.Sprintf("%.1f,%.1f", x(i), y(f))
+		}
+		fmt.Fprintf(bw, "<polyline fill=\"none\" stroke=\"%s\" points=\"%s\"/>\n", color, strings.Join(points, " "))
+		fmt.Fprintf(bw, "<circle cx=\"%.1f\" cy=\"%.1f\" r=\"4\" fill=\"%s\"/>\n", x(c.best), y(c.fuel[c.best]), color)
+		fmt.Fprintf(bw, "<text x=\"%.1f\" y=\"%.1f\" fill=\"%s\" text-anchor=\"middle\">%d</text>\n", x(c.best), y(c.fuel[c.best])+16, color, c.pos(c.best))
+		fmt.Fprintf(bw, "<text x=\"%d\" y=\"%d\" fill=\"%s\">%s: optimum at %d with %d fuel</text>\n", svgMargin, svgMargin/2+14*n, color, c.name, c.pos(c.best), c.fuel[c.best])
+	}
+
+	fmt.Fprintln(bw, "</svg>")
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("unable to write svg: %w", err)
+	}
+
+	return nil
+}
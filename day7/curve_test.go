@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCurveASCII(t *testing.T) {
+	tests := []struct {
+		name string
+		nums map[int]int
+		// axis is the last line of the chart
+		axis string
+	}{
+		{name: "single position", nums: map[int]int{5: 1}, axis: "5"},
+		{name: "two positions", nums: map[int]int{5: 1, 6: 2}, axis: "5 6"},
+		{name: "spread out", nums: map[int]int{0: 1, 1: 2, 2: 3, 4: 1, 7: 1, 14: 1, 16: 1}, axis: "0              16"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := getCurve("linear", tt.nums)
+			if err != nil {
+				t.Fatalf("unable to get curve: %v", err)
+			}
+			var buf bytes.Buffer
+			if err := writeCurveASCII(&buf, []curve{c}); err != nil {
+				t.Fatalf("unable to write chart: %v", err)
+			}
+
+			lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+			if got := strings.TrimSpace(lines[len(lines)-1]); got != tt.axis {
+				t.Errorf("axis is %q, want %q", got, tt.axis)
+			}
+
+			// the most expensive column reaches the top row, unless every
+			// position costs the same
+			top := lines[1]
+			if len(tt.nums) > 1 && !strings.Contains(top, "#") {
+				t.Errorf("top row is empty:\n%s", buf.String())
+			}
+			if !strings.Contains(buf.String(), "*") {
+				t.Errorf("optimum isn't marked:\n%s", buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// solver finds the position the crabs should align at and the fuel it costs
+type solver func(part string, nums map[int]int) (int, int, error)
+
+func getSolver(name string) (solver, error) {
+	switch name {
+	case "exact":
+		return exactFuel, nil
+	case "scan":
+		return getMinFuel, nil
+	default:
+		return nil, fmt.Errorf("unsupported solver: %s", name)
+	}
+}
+
+// exactFuel goes straight to the optimum. moving one step right adds a fuel
+// for every crab on the left and saves one for every crab on the right, so
+// the linear cost is lowest at the median. the triangular cost is the linear
+// cost plus the squares, half of which is lowest at the mean, so the optimum
+// is within half a step of the mean and one of the two positions around it
+// wins. any other cost has to be scanned.
+func exactFuel(part string, nums map[int]int) (int, int, error) {
+	if len(nums) == 0 {
+		return 0, 0, fmt.Errorf("no crabs to align")
+	}
+
+	var candidates []int
+	switch part {
+	case "part1", "linear":
+		candidates = []int{median(nums)}
+	case "part2", "triangular":
+		m := mean(nums)
+		candidates = []int{m, m + 1}
+	default:
+		return 0, 0, fmt.Errorf("the exact solver only supports the linear and triangular costs, got %s", part)
+	}
+
+	step, err := getStepper(part)
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable to get stepper: %w", err)
+	}
+
+	minPos := candidates[0]
+	minFuel := fuelAt(step, nums, minPos)
+	for _, pos := range candidates[1:] {
+		if f := fuelAt(step, nums, pos); f < minFuel {
+			minFuel, minPos = f, pos
+		}
+	}
+
+	return minFuel, minPos, nil
+}
+
+// median is the lowest position with at least half of the crabs at or left
+// of it
+func median(nums map[int]int) int {
+	positions := make([]int, 0, len(nums))
+	var crabs int
+	for pos, count := range nums {
+		positions = append(positions, pos)
+		crabs += count
+	}
+	sort.Ints(positions)
+
+	var seen int
+	for _, pos := range positions {
+		seen += nums[pos]
+		if 2*seen >= crabs {
+			return pos
+		}
+	}
+
+	return positions[len(positions)-1]
+}
+
+// mean is rounded down, towards -inf for negative positions
+func mean(nums map[int]int) int {
+	var sum, crabs int
+	for pos, count := range nums {
+		sum += pos * count
+		crabs += count
+	}
+
+	m := sum / crabs
+	if sum%crabs != 0 && sum < 0 {
+		m--
+	}
+
+	return m
+}
+
+func fuelAt(step stepper, nums map[int]int, alignAt int) int {
+	var fuel int
+	for pos, count := range nums {
+		fuel += step(alignAt, pos, count)
+	}
+
+	return fuel
+}
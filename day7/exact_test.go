@@ -0,0 +1,121 @@
+package main
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"testing"
+)
+
+// # of random inputs the solvers are compared on
+const randomInputs = 2000
+
+// TestSolvers makes sure the exact solver and the scan spend the same fuel
+// for both parts of the input and random inputs. the positions can differ
+// when there's a tie
+func TestSolvers(t *testing.T) {
+	data, err := ioutil.ReadFile("input.txt")
+	if err != nil {
+		t.Fatalf("unable to read input: %v", err)
+	}
+	nums, err := getNums(data)
+	if err != nil {
+		t.Fatalf("unable to get nums: %v", err)
+	}
+	compareSolvers(t, nums)
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < randomInputs; i++ {
+		compareSolvers(t, randomNums(rng, 1+rng.Intn(20), 1+rng.Intn(50)))
+	}
+}
+
+// TestRegressions covers the search bounds the scan used to get wrong: the
+// lowest bound was taken from a count instead of a position, the highest was
+// skipped when the lowest moved and was never tried itself
+func TestRegressions(t *testing.T) {
+	tests := []struct {
+		name string
+		nums map[int]int
+		// fuel and pos for part 1 and part 2
+		fuel [2]int
+		pos  [2]int
+	}{
+		{name: "example", nums: map[int]int{16: 1, 1: 2, 2: 3, 0: 1, 4: 1, 7: 1, 14: 1}, fuel: [2]int{37, 168}, pos: [2]int{2, 5}},
+		{name: "single position", nums: map[int]int{5: 3}, fuel: [2]int{0, 0}, pos: [2]int{5, 5}},
+		{name: "two ends", nums: map[int]int{0: 1, 18: 1}, fuel: [2]int{18, 90}, pos: [2]int{0, 9}},
+		{name: "optimum at the highest", nums: map[int]int{0: 1, 1: 10}, fuel: [2]int{1, 1}, pos: [2]int{1, 1}},
+		{name: "optimum at the lowest", nums: map[int]int{3: 10, 4: 1}, fuel: [2]int{1, 1}, pos: [2]int{3, 3}},
+		{name: "count below the lowest", nums: map[int]int{10: 1, 12: 5}, fuel: [2]int{2, 3}, pos: [2]int{12, 12}},
+		{name: "negative positions", nums: map[int]int{-7: 2, -3: 1}, fuel: [2]int{4, 8}, pos: [2]int{-7, -6}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, part := range []string{"part1", "part2"} {
+				for _, name := range []string{"exact", "scan"} {
+					solve, err := getSolver(name)
+					if err != nil {
+						t.Fatalf("unable to get solver: %v", err)
+					}
+					fuel, pos, err := solve(part, tt.nums)
+					if err != nil {
+						t.Fatalf("%s %s: %v", name, part, err)
+					}
+					if fuel != tt.fuel[i] || pos != tt.pos[i] {
+						t.Errorf("%s %s spent %d fuel at %d, want %d at %d", name, part, fuel, pos, tt.fuel[i], tt.pos[i])
+					}
+				}
+			}
+		})
+	}
+}
+
+func compareSolvers(t *testing.T, nums map[int]int) {
+	t.Helper()
+
+	for _, part := range []string{"part1", "part2"} {
+		want, wantPos, err := getMinFuel(part, nums)
+		if err != nil {
+			t.Fatalf("%s: scan: %v", part, err)
+		}
+		got, pos, err := exactFuel(part, nums)
+		if err != nil {
+			t.Fatalf("%s: exact: %v", part, err)
+		}
+		if got != want {
+			t.Fatalf("%s: exact spent %d fuel at %d, scan spent %d at %d in %v", part, got, pos, want, wantPos, nums)
+		}
+	}
+}
+
+// randomNums puts n crabs in [0, size)
+func randomNums(rng *rand.Rand, n, size int) map[int]int {
+	nums := make(map[int]int)
+	for i := 0; i < n; i++ {
+		nums[rng.Intn(size)]++
+	}
+
+	return nums
+}
+
+// TestExactCosts makes sure the exact solver takes the cost names as well as
+// the parts and refuses the costs it can't go straight to the optimum for
+func TestExactCosts(t *testing.T) {
+	example := map[int]int{16: 1, 1: 2, 2: 3, 0: 1, 4: 1, 7: 1, 14: 1}
+	for name, want := range map[string]int{"part1": 37, "linear": 37, "part2": 168, "triangular": 168} {
+		fuel, _, err := exactFuel(name, example)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if fuel != want {
+			t.Errorf("%s: spent %d fuel, want %d", name, fuel, want)
+		}
+	}
+
+	for _, name := range []string{"quadratic", "capped:5", "weighted:2", "expr:d*d", "nope"} {
+		if _, _, err := exactFuel(name, example); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
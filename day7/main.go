@@ -1,7 +1,7 @@
 package main
 
 import (
-	"errors"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -13,7 +13,23 @@ import (
 const input = "day7/input.txt"
 
 func main() {
-	data, err := ioutil.ReadFile(input)
+	name := flag.String("solver", "exact", "how the optimum is found: exact or scan")
+	costName := flag.String("cost", "", "only find the optimum for this cost: linear, triangular, quadratic, capped:N, weighted:BASE:POS=W,... or expr:EXPRESSION")
+	path := flag.String("input", input, "path to the puzzle input")
+	plane := flag.Bool("2d", false, "read x,y pairs and align the crabs at a point")
+	dist := flag.String("distance", "manhattan", "how far apart points are in 2d mode: manhattan or chebyshev")
+	format := flag.String("curve", "", "write the fuel at every position as csv, ascii or svg instead of solving")
+	out := flag.String("out", "-", "where to write the curve, - for stdout")
+	flag.Parse()
+
+	if *plane {
+		if err := align2D(*path, *dist, *costName); err != nil {
+			log.Fatalf("unable to align in 2d: %v", err)
+		}
+		return
+	}
+
+	data, err := ioutil.ReadFile(*path)
 	if err != nil {
 		log.Fatalf("unable to read input file: %v", err)
 	}
@@ -23,54 +39,119 @@ func main() {
 		log.Fatalf("unable to get nums: %v", err)
 	}
 
-	fuel, pos, err := getMinFuel("part1", nums)
+	if *format != "" {
+		if err := curveFile(*out, *format, *costName, nums); err != nil {
+			log.Fatalf("unable to write curve: %v", err)
+		}
+		return
+	}
+
+	if *costName != "" {
+		c, err := getCost(*costName)
+		if err != nil {
+			log.Fatalf("unable to get cost: %v", err)
+		}
+		fuel, pos, err := optimize(c, nums)
+		if err != nil {
+			log.Fatalf("unable to optimize: %v", err)
+		}
+		fmt.Printf("%s answer: pos: %d, fuel:%d\n", *costName, pos, fuel)
+		return
+	}
+
+	solve, err := getSolver(*name)
+	if err != nil {
+		log.Fatalf("unable to get solver: %v", err)
+	}
+
+	fuel, pos, err := solve("part1", nums)
 	if err != nil {
 		log.Fatalf("unable to get min fuel for part 1: %v", err)
 	}
 
 	fmt.Printf("part 1 answer: pos: %d, fuel:%d\n", pos, fuel)
 
-	fuel, pos, err = getMinFuel("part2", nums)
+	fuel, pos, err = solve("part2", nums)
 	if err != nil {
 		log.Fatalf("unable to get min fuel for part 1: %v", err)
 	}
 	fmt.Printf("part 2 answer: pos: %d, fuel:%d\n", pos, fuel)
 }
 
-// for each fuel we iterate through the rest of the positions to get
-// the least min fuels, O(k^2) where k is the # of unique numbers
+// align2D prints the optimum for both parts, or only for the cost given
+func align2D(path, distName, costName string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read input file: %w", err)
+	}
+
+	points, err := getPoints(data)
+	if err != nil {
+		return fmt.Errorf("unable to get points: %w", err)
+	}
+
+	dist, err := getDistance(distName)
+	if err != nil {
+		return err
+	}
+
+	names := []string{"part1", "part2"}
+	if costName != "" {
+		names = []string{costName}
+	}
+	for _, name := range names {
+		c, err := getCost(name)
+		if err != nil {
+			return fmt.Errorf("unable to get cost: %w", err)
+		}
+		fuel, p, err := optimize2D(c, dist, points)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s answer: pos: %d,%d, fuel:%d\n", strings.Replace(name, "part", "part ", 1), p.x, p.y, fuel)
+	}
+
+	return nil
+}
+
+// for each position between the outermost crabs we iterate through the
+// unique positions to get the least min fuels, O(range·k) where range is the
+// distance between the outermost crabs and k is the # of unique numbers
 func getMinFuel(part string, nums map[int]int) (int, int, error) {
+	if len(nums) == 0 {
+		return 0, 0, fmt.Errorf("no crabs to align")
+	}
+
+	stepper, err := getStepper(part)
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable to get stepper: %w", err)
+	}
+
 	minFuel := math.MaxInt
 	minPos := -1
 	var (
 		// get bounds to search
 		min int = math.MaxInt
-		max int = -1
+		max int = math.MinInt
 	)
 	for k := range nums {
 		if k < min {
-			min = nums[k]
-			continue
+			min = k
 		}
 
 		if k > max {
 			max = k
 		}
 	}
-	// search within bounds. this is just an assumption of how the problem
-	// should be done. its not really clear on how to actually do it based
-	// on the directions
-	for i := min; i < max; i++ {
+	// search within bounds, both ends included. moving past the outermost
+	// crabs only ever costs more
+	for i := min; i <= max; i++ {
 		curFuel := 0
 		for pos, count := range nums {
 			if pos == i {
 				continue
 			}
 
-			stepper, err := getStepper(part)
-			if err != nil {
-				return 0, 0, fmt.Errorf("unable to get stepper: %w", err)
-			}
 			curFuel += stepper(i, pos, count)
 		}
 		if curFuel < minFuel {
@@ -104,21 +185,14 @@ func getNums(data []byte) (map[int]int, error) {
 
 type stepper func(alignAt, pos, count int) int
 
-func getStepper(part string) (stepper, error) {
-	switch part {
-	case "part1":
-		return func(alignAt, pos, count int) int {
-			return absSub(alignAt, pos) * count
-		}, nil
-	case "part2":
-		return func(alignAt, pos, count int) int {
-			steps := absSub(alignAt, pos)
-			// n(n+1)/2
-			return ((steps * (steps + 1)) / 2) * count
-		}, nil
-	default:
-		return nil, errors.New("unsupported part")
+// getStepper looks the cost up by name, see getCost
+func getStepper(name string) (stepper, error) {
+	c, err := getCost(name)
+	if err != nil {
+		return nil, err
 	}
+
+	return c.step, nil
 }
 
 func absSub(i, j int) int {
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// point is where a crab sits in 2d mode
+type point struct {
+	x int
+	y int
+}
+
+// distance is how many steps apart two points are
+type distance func(a, b point) int
+
+func getDistance(name string) (distance, error) {
+	switch name {
+	case "manhattan":
+		return func(a, b point) int {
+			return absSub(a.x, b.x) + absSub(a.y, b.y)
+		}, nil
+	case "chebyshev":
+		// diagonal steps cost the same as straight ones
+		return func(a, b point) int {
+			return maxInt(absSub(a.x, b.x), absSub(a.y, b.y))
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported distance: %s", name)
+	}
+}
+
+// getPoints reads x,y pairs split up by spaces or newlines
+func getPoints(data []byte) (map[point]int, error) {
+	// point -> count
+	points := make(map[point]int)
+	for _, field := range strings.Fields(string(data)) {
+		parts := strings.Split(field, ",")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("unexpected form of point: %s", field)
+		}
+		x, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("unable to get x coord: %w", err)
+		}
+		y, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("unable to get y coord: %w", err)
+		}
+		points[point{x: x, y: y}]++
+	}
+
+	if len(points) == 0 {
+		return nil, fmt.Errorf("no crabs to align")
+	}
+
+	return points, nil
+}
+
+// fuelAtPoint runs the distance through the stepper as if every crab were
+// that many steps away on a line from 0, which is why per crab costs can't
+// be used
+func fuelAtPoint(step stepper, dist distance, points map[point]int, alignAt point) int {
+	var fuel int
+	for p, count := range points {
+		fuel += step(dist(alignAt, p), 0, count)
+	}
+
+	return fuel
+}
+
+// optimize2D finds the cheapest point inside the box around the crabs. with a
+// convex cost each row's cheapest fuel is convex over the columns, so it's a
+// ternary search over the columns with another one down each column.
+// everything else tries every point
+func optimize2D(c cost, dist distance, points map[point]int) (int, point, error) {
+	if len(points) == 0 {
+		return 0, point{}, fmt.Errorf("no crabs to align")
+	}
+	if c.perCrab {
+		return 0, point{}, fmt.Errorf("per crab costs only work on a line")
+	}
+
+	lo, hi := point{x: math.MaxInt, y: math.MaxInt}, point{x: math.MinInt, y: math.MinInt}
+	for p := range points {
+		lo.x, lo.y = minInt(lo.x, p.x), minInt(lo.y, p.y)
+		hi.x, hi.y = maxInt(hi.x, p.x), maxInt(hi.y, p.y)
+	}
+
+	column := func(x int) (int, int) {
+		at := func(y int) int {
+			return fuelAtPoint(c.step, dist, points, point{x: x, y: y})
+		}
+		if c.convex {
+			return ternary(at, lo.y, hi.y)
+		}
+		return minOver(at, lo.y, hi.y)
+	}
+
+	var best point
+	at := func(x int) int {
+		fuel, _ := column(x)
+		return fuel
+	}
+	var fuel int
+	if c.convex {
+		fuel, best.x = ternary(at, lo.x, hi.x)
+	} else {
+		fuel, best.x = minOver(at, lo.x, hi.x)
+	}
+	_, best.y = column(best.x)
+
+	return fuel, best, nil
+}
+
+// ternary narrows [lo, hi] down around the minimum of a convex f, see optimize
+func ternary(f func(int) int, lo, hi int) (int, int) {
+	for hi-lo > 2 {
+		m1, m2 := lo+(hi-lo)/3, hi-(hi-lo)/3
+		f1, f2 := f(m1), f(m2)
+		switch {
+		case f1 < f2:
+			hi = m2 - 1
+		case f1 > f2:
+			lo = m1 + 1
+		default:
+			lo, hi = m1, m2
+		}
+	}
+
+	return minOver(f, lo, hi)
+}
+
+// minOver tries every i in [lo, hi], ties go to the lowest
+func minOver(f func(int) int, lo, hi int) (int, int) {
+	min, at := math.MaxInt, lo
+	for i := lo; i <= hi; i++ {
+		if v := f(i); v < min {
+			min, at = v, i
+		}
+	}
+
+	return min, at
+}
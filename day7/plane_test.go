@@ -0,0 +1,60 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// TestPlane makes sure the nested ternary search spends the same fuel as
+// trying every point for random crabs
+func TestPlane(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < randomInputs/4; i++ {
+		points := make(map[point]int)
+		n, size := 1+rng.Intn(15), 1+rng.Intn(30)
+		for j := 0; j < n; j++ {
+			points[point{x: rng.Intn(size), y: rng.Intn(size)}]++
+		}
+
+		for _, d := range []string{"manhattan", "chebyshev"} {
+			dist, err := getDistance(d)
+			if err != nil {
+				t.Fatalf("unable to get distance: %v", err)
+			}
+			for _, name := range []string{"linear", "triangular", "quadratic"} {
+				c, err := getCost(name)
+				if err != nil {
+					t.Fatalf("unable to get cost: %v", err)
+				}
+				got, p, err := optimize2D(c, dist, points)
+				if err != nil {
+					t.Fatalf("%s %s: %v", d, name, err)
+				}
+				c.convex = false
+				want, wantP, err := optimize2D(c, dist, points)
+				if err != nil {
+					t.Fatalf("%s %s: %v", d, name, err)
+				}
+				if got != want {
+					t.Fatalf("random input %d: %s %s search spent %d fuel at %v, full scan spent %d at %v in %v", i, d, name, got, p, want, wantP, points)
+				}
+			}
+		}
+	}
+}
+
+func TestPlanePerCrab(t *testing.T) {
+	c, err := getCost("weighted:linear:10=100")
+	if err != nil {
+		t.Fatalf("unable to get cost: %v", err)
+	}
+	dist, err := getDistance("manhattan")
+	if err != nil {
+		t.Fatalf("unable to get distance: %v", err)
+	}
+
+	points := map[point]int{{x: 0, y: 0}: 1, {x: 10, y: 0}: 1, {x: 10, y: 10}: 1}
+	if _, _, err := optimize2D(c, dist, points); err == nil {
+		t.Fatalf("a weighted cost was used in 2d")
+	}
+}